@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/constant"
+	"go/format"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"path"
+	"text/template"
+)
+
+var fileTpl = template.Must(template.New("file").Parse(`// Code generated by parens-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- if .UsesReflect}}
+	"reflect"
+{{end}}
+	{{if .Alias}}{{.Alias}} {{end}}"{{.ImportPath}}"
+	"github.com/spy16/parens"
+)
+
+func init() {
+	parens.RegisterPackage("{{.Name}}", map[string]interface{}{
+{{- range .Members}}
+		"{{.Name}}": {{.Expr}},
+{{- end}}
+	})
+}
+`))
+
+type member struct {
+	Name string
+	Expr string
+}
+
+// generate type-checks the package with the given import path and returns
+// the formatted source registering its exported members under name.
+func generate(importPath, name, pkgName string) ([]byte, error) {
+	pkg, err := importer.ForCompiler(token.NewFileSet(), "source", nil).Import(importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	alias := pkg.Name()
+	if (alias == "parens" || alias == "reflect") && importPath != "reflect" {
+		alias = alias + "pkg"
+	}
+
+	var members []member
+	usesReflect := false
+	for _, objName := range pkg.Scope().Names() {
+		obj := pkg.Scope().Lookup(objName)
+		if !obj.Exported() {
+			continue
+		}
+
+		expr, ok := memberExpr(alias, obj)
+		if !ok {
+			continue
+		}
+
+		if _, isType := obj.(*types.TypeName); isType {
+			usesReflect = true
+		}
+		members = append(members, member{Name: objName, Expr: expr})
+	}
+
+	if len(members) == 0 {
+		return nil, fmt.Errorf("package '%s' has no exported functions, types or constants", importPath)
+	}
+
+	var buf bytes.Buffer
+	err = fileTpl.Execute(&buf, map[string]interface{}{
+		"Package":     pkgName,
+		"Name":        name,
+		"Alias":       importAlias(importPath, alias),
+		"ImportPath":  importPath,
+		"UsesReflect": usesReflect && importPath != "reflect",
+		"Members":     members,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// memberExpr returns the Go expression for the value of the package member.
+// ok is false for members that cannot be used as a value (e.g., generic
+// functions and types) and for variables.
+func memberExpr(alias string, obj types.Object) (expr string, ok bool) {
+	qualified := alias + "." + obj.Name()
+
+	switch obj := obj.(type) {
+	case *types.Func:
+		if isGeneric(obj) {
+			return "", false
+		}
+		return qualified, true
+
+	case *types.TypeName:
+		if isGeneric(obj) {
+			return "", false
+		}
+
+		reflectPkg := "reflect"
+		if alias == "reflect" {
+			reflectPkg = alias
+		}
+		return fmt.Sprintf("%s.TypeOf((*%s)(nil)).Elem()", reflectPkg, qualified), true
+
+	case *types.Const:
+		return constExpr(qualified, obj)
+	}
+
+	return "", false
+}
+
+// constExpr converts untyped integer constants to int64 (the integer type
+// used by parens) or to uint64 if they do not fit into int64.
+func constExpr(qualified string, obj *types.Const) (string, bool) {
+	basic, isBasic := obj.Type().(*types.Basic)
+	if !isBasic || basic.Info()&types.IsUntyped == 0 || basic.Info()&types.IsInteger == 0 {
+		return qualified, true
+	}
+
+	if _, exact := constant.Int64Val(obj.Val()); exact {
+		return fmt.Sprintf("int64(%s)", qualified), true
+	} else if _, exact := constant.Uint64Val(obj.Val()); exact {
+		return fmt.Sprintf("uint64(%s)", qualified), true
+	}
+
+	return "", false
+}
+
+// importAlias returns the alias to be used in the import declaration or an
+// empty string if the package name can be used as is.
+func importAlias(importPath, alias string) string {
+	if path.Base(importPath) == alias {
+		return ""
+	}
+	return alias
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	src, err := generate("math", "go.math", "bindings")
+	require.NoError(t, err)
+
+	got := string(src)
+	assert.True(t, strings.HasPrefix(got, "// Code generated by parens-gen. DO NOT EDIT.\n\npackage bindings\n"))
+	assert.Contains(t, got, `parens.RegisterPackage("go.math", map[string]interface{}{`)
+	assert.Contains(t, got, `"Sqrt":`)
+	assert.Contains(t, got, `math.Sqrt,`)
+	assert.Contains(t, got, `int64(math.MaxInt64),`)
+	assert.Contains(t, got, `uint64(math.MaxUint64),`)
+	assert.NotContains(t, got, `"reflect"`, "reflect must be imported only if there are types")
+
+	src, err = generate("strings", "strings", "main")
+	require.NoError(t, err)
+	assert.Contains(t, string(src), `reflect.TypeOf((*strings.Builder)(nil)).Elem(),`)
+
+	src, err = generate("io", "io", "main")
+	require.NoError(t, err)
+	assert.Contains(t, string(src), `io.Copy,`)
+	assert.NotContains(t, string(src), `"EOF"`, "variables must be skipped")
+}
+
+func TestGenerate_Alias(t *testing.T) {
+	t.Parallel()
+
+	src, err := generate("github.com/spy16/parens", "parens", "main")
+	require.NoError(t, err)
+	assert.Contains(t, string(src), `parenspkg "github.com/spy16/parens"`)
+	assert.Contains(t, string(src), `parenspkg.NewScope,`)
+}
+
+func TestGenerate_Error(t *testing.T) {
+	t.Parallel()
+
+	_, err := generate("github.com/spy16/parens/no-such-package", "x", "main")
+	assert.Error(t, err)
+}
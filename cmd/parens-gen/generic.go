@@ -0,0 +1,20 @@
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import "go/types"
+
+// isGeneric returns true if the function or type has type parameters and
+// hence cannot be used without instantiation.
+func isGeneric(obj types.Object) bool {
+	switch typ := obj.Type().(type) {
+	case *types.Signature:
+		return typ.TypeParams().Len() > 0
+
+	case *types.Named:
+		return typ.TypeParams().Len() > 0
+	}
+
+	return false
+}
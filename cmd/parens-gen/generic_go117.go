@@ -0,0 +1,10 @@
+//go:build !go1.18
+// +build !go1.18
+
+package main
+
+import "go/types"
+
+// isGeneric always returns false since packages type-checked by toolchains
+// older than go1.18 cannot have type parameters.
+func isGeneric(obj types.Object) bool { return false }
@@ -0,0 +1,20 @@
+//go:build go1.19
+// +build go1.19
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_Generic(t *testing.T) {
+	t.Parallel()
+
+	src, err := generate("sync/atomic", "sync.atomic", "main")
+	require.NoError(t, err)
+	assert.Contains(t, string(src), `reflect.TypeOf((*atomic.Int64)(nil)).Elem(),`)
+	assert.NotContains(t, string(src), `"Pointer"`, "generic types must be skipped")
+}
@@ -0,0 +1,66 @@
+// Command parens-gen generates Go source that registers the exported
+// functions, types and constants of a Go package with parens.RegisterPackage
+// so that scripts can use them via (require 'pkg). It is meant to be used
+// with go generate:
+//
+//	//go:generate parens-gen -o strings_parens.go strings
+//
+// Packages are registered with the import path as name where '/' is replaced
+// by '.' (e.g., encoding/json is required as 'encoding.json). Use -name to
+// register with a different name.
+//
+// Exported variables (e.g., io.EOF or os.Args) are skipped since the values
+// registered are copied once and would not reflect later changes. Generic
+// functions and types are skipped as well since they cannot be used without
+// instantiation.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func main() {
+	var out, pkgName, name string
+	flag.StringVar(&out, "o", "", "Output file (standard output if empty)")
+	flag.StringVar(&pkgName, "package", os.Getenv("GOPACKAGE"), "Package name of the generated file")
+	flag.StringVar(&name, "name", "", "Name to register the package with (default: import path with '/' replaced by '.')")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: parens-gen [flags] <import-path>\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if pkgName == "" {
+		pkgName = "main"
+	}
+
+	importPath := flag.Arg(0)
+	if name == "" {
+		name = strings.ReplaceAll(importPath, "/", ".")
+	}
+
+	src, err := generate(importPath, name, pkgName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+
+	if out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+
+	if err := ioutil.WriteFile(out, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
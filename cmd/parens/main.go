@@ -1,8 +1,8 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -21,19 +21,22 @@ func main() {
 	scope.Bind("exit", cancel)
 
 	if len(strings.TrimSpace(src)) > 0 {
-		execString(src, scope)
+		execString(ctx, src, scope)
 	} else if len(os.Args) == 2 {
-		execFile(scope)
+		execFile(ctx, scope)
 	} else {
 		runREPL(ctx, scope)
 	}
 
 }
 
-func execString(src string, env parens.Scope) {
-	val, err := parens.Execute(strings.NewReader(src), env)
-	if err != nil {
-		fmt.Printf("error: %s\n", err)
+func execString(ctx context.Context, src string, env parens.Scope) {
+	val, err := parens.ExecuteContext(ctx, strings.NewReader(src), env)
+	if err == context.Canceled {
+		// (exit) was called
+		return
+	} else if err != nil {
+		fmt.Printf("error: %s\n", formatErr(err))
 		os.Exit(1)
 	}
 
@@ -41,18 +44,17 @@ func execString(src string, env parens.Scope) {
 	return
 }
 
-func execFile(env parens.Scope) {
+func execFile(ctx context.Context, env parens.Scope) {
 	fh, err := os.Open(os.Args[1])
 	if err != nil {
 		fmt.Printf("error: %s\n", err)
 		os.Exit(1)
 	}
 	defer fh.Close()
-	rd := bufio.NewReader(fh)
 
-	_, err = parens.Execute(rd, env)
-	if err != nil {
-		fmt.Printf("error: %s\n", err)
+	_, err = parens.ExecuteContext(ctx, fh, env)
+	if err != nil && err != context.Canceled {
+		fmt.Printf("error: %s\n", formatErr(err))
 		os.Exit(1)
 	}
 	return
@@ -66,3 +68,14 @@ func runREPL(ctx context.Context, env parens.Scope) {
 	repl.Banner = "Welcome to Parens REPL!\nType \"(?)\" for help!"
 	repl.Start(ctx)
 }
+
+// formatErr returns the backtrace for evaluation errors and the plain error
+// message otherwise.
+func formatErr(err error) string {
+	var evalErr *parens.EvalError
+	if errors.As(err, &evalErr) {
+		return evalErr.Backtrace()
+	}
+
+	return err.Error()
+}
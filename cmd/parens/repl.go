@@ -47,7 +47,7 @@ func (repl *REPL) Start(ctx context.Context) error {
 			return nil
 
 		default:
-			shouldExit := repl.readAndExecute()
+			shouldExit := repl.readAndExecute(ctx)
 			if shouldExit {
 				repl.WriteOut("Bye!", nil)
 				return nil
@@ -56,7 +56,7 @@ func (repl *REPL) Start(ctx context.Context) error {
 	}
 }
 
-func (repl *REPL) readAndExecute() bool {
+func (repl *REPL) readAndExecute(ctx context.Context) bool {
 	expr, err := repl.ReadIn()
 	if err != nil {
 		if err == io.EOF {
@@ -70,7 +70,7 @@ func (repl *REPL) readAndExecute() bool {
 		return false
 	}
 
-	repl.WriteOut(parens.Execute(strings.NewReader(expr), repl.Env))
+	repl.WriteOut(parens.ExecuteContext(ctx, strings.NewReader(expr), repl.Env))
 	return false
 }
 
@@ -104,14 +104,14 @@ func (pr *prompter) readIn() (string, error) {
 
 func (pr *prompter) writeOut(v interface{}, err error) {
 	if err != nil {
-		pr.ins.Write([]byte(fmt.Sprintf("error: %s\n", err)))
+		pr.ins.Write([]byte(fmt.Sprintf("error: %s\n", formatErr(err))))
 		return
 	}
 	pr.ins.Write([]byte(formatResult(v) + "\n"))
 }
 
 func formatResult(v interface{}) string {
-	if v == nil {
+	if parens.IsNil(v) {
 		return "nil"
 	}
 	rval := reflect.ValueOf(v)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/spy16/parens"
 	"github.com/spy16/parens/stdlib"
@@ -33,24 +34,29 @@ func makeGlobalScope() parens.Scope {
 		return help
 	})
 
-	// user-defined values can be exposed too and their methods
-	// can be accessed.
-	st := &sampleType{val: "initial"}
+	// user-defined values can be exposed too and their methods and
+	// exported fields can be accessed. e.g., (.SetVal sample "hello")
+	// and (.-Val sample)
+	st := &sampleType{Val: "initial"}
 	scope.Bind("sample", st)
 
+	// types can be exposed for creating new instances using
+	// (new SampleType :Val "hello").
+	scope.Bind("SampleType", reflect.TypeOf(sampleType{}))
+
 	stdlib.RegisterAll(scope)
 	return scope
 }
 
 type sampleType struct {
-	val string
+	Val string
 }
 
 func (st *sampleType) SetVal(s string) string {
-	st.val = s
+	st.Val = s
 	return s
 }
 
 func (st sampleType) String() string {
-	return fmt.Sprintf("sampleType[val=%s]", st.val)
+	return fmt.Sprintf("sampleType[Val=%s]", st.Val)
 }
@@ -0,0 +1,163 @@
+package parens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// ExecuteContext is same as Execute but evaluation is stopped with ctx.Err()
+// when the context is cancelled or its deadline is exceeded.
+func ExecuteContext(ctx context.Context, rd io.Reader, env Scope) (interface{}, error) {
+	expr, err := Parse(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	return ExecuteExprContext(ctx, expr, env)
+}
+
+// ExecuteStrContext is a convenience wrapper for ExecuteContext.
+func ExecuteStrContext(ctx context.Context, src string, env Scope) (interface{}, error) {
+	return ExecuteContext(ctx, strings.NewReader(src), env)
+}
+
+// ExecuteExprContext is same as ExecuteExpr but the context is checked
+// between evaluation of forms and before every function invocation. If the
+// context is done, ctx.Err() is returned. The context can be obtained from
+// the scope using ContextOf and is passed to bound Go functions that accept
+// context.Context as the first argument.
+func ExecuteExprContext(ctx context.Context, expr Expr, env Scope) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res, err := ExecuteExpr(expr, WithContext(ctx, env))
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// WithContext returns a scope that carries ctx and delegates everything else
+// to the given scope. Bindings made in the returned scope go to the given
+// scope itself.
+func WithContext(ctx context.Context, scope Scope) Scope {
+	return &contextScope{
+		Scope: scope,
+		ctx:   ctx,
+	}
+}
+
+// ContextOf returns the context associated with the scope or any of its
+// parents. context.Background() is returned if there is none.
+func ContextOf(scope Scope) context.Context {
+	if sc, ok := scope.(scopeWithContext); ok {
+		return sc.Context()
+	}
+
+	return context.Background()
+}
+
+// Detach returns a context that carries the values of ctx but is never
+// cancelled, has no deadline and is not subject to the limits set using
+// ExecuteWithOptions. It can be used for evaluations whose results outlive
+// the evaluation that started them (e.g., loading a shared module).
+func Detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) { return }
+func (detachedContext) Done() <-chan struct{}                   { return nil }
+func (detachedContext) Err() error                              { return nil }
+
+func (dc detachedContext) Value(key interface{}) interface{} {
+	if _, isLimiter := key.(limiterKey); isLimiter {
+		return nil
+	}
+	return dc.parent.Value(key)
+}
+
+type contextScope struct {
+	Scope
+	ctx context.Context
+}
+
+func (sc *contextScope) Context() context.Context { return sc.ctx }
+
+func (sc *contextScope) Doc(name string) string {
+	if swd, ok := sc.Scope.(scopeWithDoc); ok {
+		return swd.Doc(name)
+	}
+
+	return ""
+}
+
+func (sc *contextScope) Set(name string, v interface{}) error {
+	if ms, ok := sc.Scope.(MutableScope); ok {
+		return ms.Set(name, v)
+	}
+
+	return fmt.Errorf("cannot set '%s': scope of type '%T' is not mutable", name, sc.Scope)
+}
+
+func (sc *contextScope) Unbind(name string) error {
+	if ms, ok := sc.Scope.(MutableScope); ok {
+		return ms.Unbind(name)
+	}
+
+	return fmt.Errorf("cannot unbind '%s': scope of type '%T' is not mutable", name, sc.Scope)
+}
+
+func (sc *contextScope) Names() []string {
+	if is, ok := sc.Scope.(IntrospectableScope); ok {
+		return is.Names()
+	}
+
+	return nil
+}
+
+func (sc *contextScope) Meta(name string) (Meta, bool) {
+	if is, ok := sc.Scope.(IntrospectableScope); ok {
+		return is.Meta(name)
+	}
+
+	if _, err := sc.Scope.Get(name); err == nil {
+		return Meta{Doc: sc.Doc(name)}, true
+	}
+	return Meta{}, false
+}
+
+func (sc *contextScope) BindMeta(name string, v interface{}, meta Meta) error {
+	if is, ok := sc.Scope.(IntrospectableScope); ok {
+		return is.BindMeta(name, v, meta)
+	}
+
+	return sc.Scope.Bind(name, v, meta.Doc)
+}
+
+func (sc *contextScope) String() string {
+	if s, ok := sc.Scope.(interface{ String() string }); ok {
+		return s.String()
+	}
+
+	return ""
+}
+
+type scopeWithContext interface {
+	Context() context.Context
+}
@@ -0,0 +1,82 @@
+package parens
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey string
+
+func TestExecuteContext_Cancellation(t *testing.T) {
+	scope := NewScope(nil)
+	scope.Bind("noop", func() {})
+	scope.Bind("forever", MacroFunc(func(scope Scope, exprs []Expr) (interface{}, error) {
+		for {
+			if _, err := exprs[0].Eval(scope); err != nil {
+				return nil, err
+			}
+		}
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	res, err := ExecuteStrContext(ctx, "(forever (noop))", scope)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Nil(t, res)
+}
+
+func TestExecuteContext_CancelledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	scope := NewScope(nil)
+	scope.Bind("call", func() { called = true })
+
+	_, err := ExecuteStrContext(ctx, "(call)", scope)
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, called)
+}
+
+func TestExecuteContext_CancelBetweenForms(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := 0
+	scope := NewScope(nil)
+	scope.Bind("cancel", func() { count++; cancel() })
+
+	_, err := ExecuteStrContext(ctx, "(cancel) 10 (cancel)", scope)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestExecuteContext_ContextInGoFunc(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey("user"), "bob")
+
+	scope := NewScope(nil)
+	scope.Bind("user", func(ctx context.Context, greeting string) string {
+		return greeting + " " + ctx.Value(ctxKey("user")).(string)
+	})
+	scope.Bind("user-of-scope", MacroFunc(func(scope Scope, exprs []Expr) (interface{}, error) {
+		return ContextOf(NewScope(scope)).Value(ctxKey("user")), nil
+	}))
+
+	res, err := ExecuteStrContext(ctx, `(user "hello")`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, "hello bob", res)
+
+	res, err = ExecuteStrContext(ctx, `(user-of-scope)`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", res)
+}
+
+func TestContextOf_Default(t *testing.T) {
+	assert.Equal(t, context.Background(), ContextOf(NewScope(nil)))
+	assert.Equal(t, context.Background(), ContextOf(NewScope(NewScope(nil))))
+}
@@ -0,0 +1,84 @@
+package parens
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// DefaultConverters is used for converting values when no Converters are
+// set using Options.
+var DefaultConverters = NewConverters()
+
+type convertersKey struct{}
+
+// Converter converts a value into another. Converters registered for a
+// target type receive the Lisp value being passed to a Go function and
+// reverse converters receive the value returned by a Go function.
+type Converter func(v interface{}) (interface{}, error)
+
+// Converters is a registry of Converters keyed by type. Converters for a
+// target type are consulted before the built-in conversion rules when
+// values are passed to bound Go functions. Reverse converters for a source
+// type decide how Go values are presented back to Lisp.
+type Converters struct {
+	mu      sync.RWMutex
+	to      map[reflect.Type]Converter
+	reverse map[reflect.Type]Converter
+}
+
+// NewConverters initializes an empty converter registry.
+func NewConverters() *Converters {
+	return &Converters{
+		to:      map[reflect.Type]Converter{},
+		reverse: map[reflect.Type]Converter{},
+	}
+}
+
+// Register sets the converter to be used when a value is passed where the
+// target type is expected. Result of the converter is further converted
+// using the built-in rules if it is not of the target type.
+func (cs *Converters) Register(target reflect.Type, conv Converter) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.to[target] = conv
+}
+
+// RegisterReverse sets the converter to be used when a value of the source
+// type is returned from a Go function to Lisp.
+func (cs *Converters) RegisterReverse(source reflect.Type, conv Converter) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.reverse[source] = conv
+}
+
+func (cs *Converters) lookup(target reflect.Type) Converter {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.to[target]
+}
+
+// fromGo applies the reverse converter registered for the type of v, if
+// any.
+func (cs *Converters) fromGo(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	cs.mu.RLock()
+	conv := cs.reverse[reflect.TypeOf(v)]
+	cs.mu.RUnlock()
+
+	if conv == nil {
+		return v, nil
+	}
+	return conv(v)
+}
+
+func convertersOf(ctx context.Context) *Converters {
+	if cs, ok := ctx.Value(convertersKey{}).(*Converters); ok && cs != nil {
+		return cs
+	}
+
+	return DefaultConverters
+}
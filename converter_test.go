@@ -0,0 +1,93 @@
+package parens_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spy16/parens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverters(t *testing.T) {
+	t.Parallel()
+
+	durationType := reflect.TypeOf(time.Duration(0))
+
+	convs := parens.NewConverters()
+	convs.Register(durationType, func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return v, nil
+		}
+		return time.ParseDuration(s)
+	})
+	convs.RegisterReverse(durationType, func(v interface{}) (interface{}, error) {
+		return v.(time.Duration).String(), nil
+	})
+
+	scope := parens.NewScope(nil)
+	scope.Bind("double", func(d time.Duration) time.Duration { return 2 * d })
+	scope.Bind("total", func(ds []time.Duration) time.Duration {
+		var sum time.Duration
+		for _, d := range ds {
+			sum += d
+		}
+		return sum
+	})
+
+	tests := []struct {
+		name    string
+		src     string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "FromString", src: `(double "1m30s")`, want: "3m0s"},
+		{name: "FallbackToBuiltin", src: `(double 1000)`, want: "2µs"},
+		{name: "InsideSlice", src: `(total ["1s" "500ms"])`, want: "1.5s"},
+		{name: "ConverterError", src: `(double "invalid")`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parens.ExecuteWithOptions(strings.NewReader(tt.src), scope, parens.Options{
+				Converters: convs,
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// converters are not used unless set in options.
+	got, err := parens.ExecuteStr(`(double 1000)`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Microsecond, got)
+}
+
+func TestConverters_ReverseError(t *testing.T) {
+	t.Parallel()
+
+	type secret string
+
+	convs := parens.NewConverters()
+	convs.RegisterReverse(reflect.TypeOf(secret("")), func(v interface{}) (interface{}, error) {
+		return nil, errors.New("secrets cannot be returned")
+	})
+
+	scope := parens.NewScope(nil)
+	scope.Bind("reveal", func() secret { return "s3cr3t" })
+
+	_, err := parens.ExecuteWithOptions(strings.NewReader("(reveal)"), scope, parens.Options{
+		Converters: convs,
+	})
+	assert.EqualError(t, err, "<string>:1:1: secrets cannot be returned")
+}
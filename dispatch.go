@@ -0,0 +1,189 @@
+package parens
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const dispatchTrigger = '#'
+
+var errNestedAnonFn = errors.New("nested #() forms are not allowed")
+
+func defaultDispatchTable() map[rune]ReaderMacro {
+	return map[rune]ReaderMacro{
+		'{': readSet,
+		'(': readAnonFn,
+		'_': discardForm,
+		'"': readRegex,
+	}
+}
+
+// readDispatch reads the rune following '#' and invokes the macro registered
+// for it in the dispatch table.
+func readDispatch(rd *Reader, _ rune) (Expr, error) {
+	r, err := rd.NextRune()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("EOF while reading dispatch macro")
+		}
+		return nil, err
+	}
+
+	macro, found := rd.dispatch[r]
+	if !found {
+		return nil, fmt.Errorf("no dispatch macro for '#%c'", r)
+	}
+
+	return macro(rd, r)
+}
+
+func readSet(rd *Reader, _ rune) (Expr, error) {
+	start := rd.position()
+
+	forms, err := readContainer(rd, '{', '}', "set")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, form := range forms {
+		key := fmt.Sprintf("%T:%v", form, form)
+		if seen[key] {
+			return nil, start.readerError(fmt.Errorf("duplicate item '%v' in set literal", form))
+		}
+		seen[key] = true
+	}
+
+	return Set{Position: start, Forms: forms}, nil
+}
+
+// readAnonFn reads the #(...) shorthand and expands it into a lambda form.
+// Arguments are referred to using %1, %2 etc. in the body and % is same as
+// %1.
+func readAnonFn(rd *Reader, init rune) (Expr, error) {
+	if rd.inAnonFn {
+		return nil, errNestedAnonFn
+	}
+
+	rd.inAnonFn = true
+	defer func() { rd.inAnonFn = false }()
+
+	body, err := readList(rd, init)
+	if err != nil {
+		return nil, err
+	}
+
+	arity := 0
+	body = replaceArgSymbols(body, &arity)
+
+	params := Vector{}
+	for i := 1; i <= arity; i++ {
+		params.Forms = append(params.Forms, Symbol{Value: "%" + strconv.Itoa(i)})
+	}
+
+	return List{
+		Forms: []Expr{Symbol{Value: "lambda"}, params, body},
+	}, nil
+}
+
+func replaceArgSymbols(form Expr, arity *int) Expr {
+	switch f := form.(type) {
+	case Symbol:
+		if f.Value == "%" {
+			f.Value = "%1"
+		}
+
+		if strings.HasPrefix(f.Value, "%") {
+			if n, err := strconv.Atoi(f.Value[1:]); err == nil && n > *arity {
+				*arity = n
+			}
+		}
+		return f
+
+	case List:
+		f.Forms = replaceAllArgSymbols(f.Forms, arity)
+		return f
+
+	case Vector:
+		f.Forms = replaceAllArgSymbols(f.Forms, arity)
+		return f
+
+	case Set:
+		f.Forms = replaceAllArgSymbols(f.Forms, arity)
+		return f
+
+	case HashMap:
+		f.Keys = replaceAllArgSymbols(f.Keys, arity)
+		f.Values = replaceAllArgSymbols(f.Values, arity)
+		return f
+	}
+
+	return form
+}
+
+func replaceAllArgSymbols(forms []Expr, arity *int) []Expr {
+	res := make([]Expr, len(forms))
+	for i, form := range forms {
+		res[i] = replaceArgSymbols(form, arity)
+	}
+	return res
+}
+
+// discardForm reads the next form and discards it.
+func discardForm(rd *Reader, _ rune) (Expr, error) {
+	_, err := rd.readOne()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("EOF while reading discard form")
+		}
+		return nil, err
+	}
+
+	return nil, ErrSkip
+}
+
+// readRegex reads a regular expression literal. Unlike string literals,
+// escape sequences other than '\"' are passed to the regex compiler as is.
+func readRegex(rd *Reader, _ rune) (Expr, error) {
+	var b strings.Builder
+
+	for {
+		r, err := rd.NextRune()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("EOF while reading regex")
+			}
+			return nil, err
+		}
+
+		if r == '\\' {
+			r2, err := rd.NextRune()
+			if err != nil {
+				if err == io.EOF {
+					return nil, errors.New("EOF while reading regex")
+				}
+				return nil, err
+			}
+
+			if r2 != '"' {
+				b.WriteRune(r)
+			}
+			r = r2
+		} else if r == '"' {
+			break
+		}
+
+		b.WriteRune(r)
+	}
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %v", err)
+	}
+
+	return Regex{Value: re}, nil
+}
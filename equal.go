@@ -0,0 +1,249 @@
+package parens
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+const (
+	fnvOffset = 2166136261
+	fnvPrime  = 16777619
+)
+
+// Equal returns true if the values are equal. Persistent collections are
+// equal if they have equal items and a PersistentVector is also equal to a
+// Go slice or array with equal items. Other values are equal if they are of
+// the same type and are == (or reflect.DeepEqual for non-comparable types).
+func Equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case *PersistentVector:
+		return equalVector(x, b)
+
+	case *PersistentMap:
+		y, ok := b.(*PersistentMap)
+		return ok && equalMap(x, y)
+
+	case *PersistentSet:
+		y, ok := b.(*PersistentSet)
+		return ok && equalMap(&x.m, &y.m)
+	}
+
+	if _, ok := b.(*PersistentVector); ok {
+		return equalVector(b.(*PersistentVector), a)
+	}
+
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	t := reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) {
+		return false
+	} else if t.Comparable() {
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Hash returns a hash of the value consistent with Equal. Error is returned
+// for values that cannot be used as map keys or set items (e.g., Go slices,
+// maps and functions).
+func Hash(v interface{}) (uint32, error) {
+	switch c := v.(type) {
+	case nil:
+		return 0, nil
+
+	case *PersistentVector:
+		h := uint32(1)
+		for _, item := range c.ToSlice() {
+			ih, err := Hash(item)
+			if err != nil {
+				return 0, err
+			}
+			h = 31*h + ih
+		}
+		return h, nil
+
+	case *PersistentMap:
+		return hashEntries(c)
+
+	case *PersistentSet:
+		return hashEntries(&c.m)
+	}
+
+	return hashValue(reflect.ValueOf(v))
+}
+
+// ToGo converts persistent collections into plain Go values recursively.
+// Vectors become []interface{}, maps become map[interface{}]interface{}
+// and sets become map[interface{}]struct{}. Items of []interface{} are
+// converted too but map keys and set items are not. Other values are
+// returned as is.
+func ToGo(v interface{}) interface{} {
+	switch c := v.(type) {
+	case *PersistentVector:
+		return ToGo(c.ToSlice())
+
+	case []interface{}:
+		items := make([]interface{}, len(c))
+		for i, item := range c {
+			items[i] = ToGo(item)
+		}
+		return items
+
+	case *PersistentMap:
+		res := make(map[interface{}]interface{}, c.Len())
+		c.Range(func(key, val interface{}) bool {
+			res[key] = ToGo(val)
+			return true
+		})
+		return res
+
+	case *PersistentSet:
+		return c.ToMap()
+	}
+
+	return v
+}
+
+func equalVector(pv *PersistentVector, other interface{}) bool {
+	if y, ok := other.(*PersistentVector); ok {
+		if pv.Len() != y.Len() {
+			return false
+		}
+
+		for i := 0; i < pv.Len(); i++ {
+			a, _ := pv.Nth(i)
+			b, _ := y.Nth(i)
+			if !Equal(a, b) {
+				return false
+			}
+		}
+		return true
+	}
+
+	rv := reflect.ValueOf(other)
+	if !isKind(rv, reflect.Slice, reflect.Array) || rv.Len() != pv.Len() {
+		return false
+	}
+
+	for i := 0; i < pv.Len(); i++ {
+		item, _ := pv.Nth(i)
+		if !Equal(item, rv.Index(i).Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalMap(a, b *PersistentMap) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+
+	equal := true
+	a.Range(func(key, val interface{}) bool {
+		other, found := b.Get(key)
+		equal = found && Equal(val, other)
+		return equal
+	})
+	return equal
+}
+
+func hashEntries(pm *PersistentMap) (uint32, error) {
+	var h uint32
+	var err error
+	pm.Range(func(key, val interface{}) bool {
+		var kh, vh uint32
+		if kh, err = Hash(key); err != nil {
+			return false
+		}
+		if vh, err = Hash(val); err != nil {
+			return false
+		}
+
+		h += kh ^ vh
+		return true
+	})
+	return h, err
+}
+
+func hashValue(rv reflect.Value) (uint32, error) {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return 0, nil
+
+	case reflect.Bool:
+		if rv.Bool() {
+			return 1231, nil
+		}
+		return 1237, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return hashUint64(uint64(rv.Int())), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return hashUint64(rv.Uint()), nil
+
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f == 0 {
+			f = 0 // -0 and +0 are equal.
+		}
+		return hashUint64(math.Float64bits(f)), nil
+
+	case reflect.Complex64, reflect.Complex128:
+		c := rv.Complex()
+		return hashUint64(math.Float64bits(real(c)))*31 + hashUint64(math.Float64bits(imag(c))), nil
+
+	case reflect.String:
+		h := uint32(fnvOffset)
+		s := rv.String()
+		for i := 0; i < len(s); i++ {
+			h = (h ^ uint32(s[i])) * fnvPrime
+		}
+		return h, nil
+
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return hashUint64(uint64(rv.Pointer())), nil
+
+	case reflect.Interface:
+		if rv.CanInterface() {
+			return Hash(rv.Interface())
+		}
+		return hashValue(rv.Elem())
+
+	case reflect.Struct, reflect.Array:
+		n := rv.NumField
+		if rv.Kind() == reflect.Array {
+			n = rv.Len
+		}
+
+		h := uint32(1)
+		for i := 0; i < n(); i++ {
+			var item reflect.Value
+			if rv.Kind() == reflect.Array {
+				item = rv.Index(i)
+			} else {
+				item = rv.Field(i)
+			}
+
+			ih, err := hashValue(item)
+			if err != nil {
+				return 0, err
+			}
+			h = 31*h + ih
+		}
+		return h, nil
+	}
+
+	return 0, fmt.Errorf("unhashable value of type '%s'", rv.Type())
+}
+
+func hashUint64(u uint64) uint32 {
+	u ^= u >> 33
+	u *= 0xff51afd7ed558ccd
+	u ^= u >> 33
+	return uint32(u)
+}
@@ -0,0 +1,120 @@
+package parens
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	maxFrameNameLen = 40
+	maxTraceLen     = 64
+)
+
+// EvalError represents a failure during evaluation. Position points to the
+// form where the failure originated and Trace contains the chain of calls
+// from the failing form up to the top-level form, innermost call first.
+type EvalError struct {
+	Position
+	Cause error
+	Trace []StackFrame
+
+	// number of outer frames not recorded in the trace since the trace
+	// was already at its maximum length.
+	skipped int
+}
+
+// StackFrame represents a single call in the trace of an EvalError. Name is
+// the name of the function invoked or the form itself when the function is
+// not a symbol.
+type StackFrame struct {
+	Position
+	Name string
+}
+
+// Unwrap returns the underlying cause of the error.
+func (ee *EvalError) Unwrap() error { return ee.Cause }
+
+// Backtrace returns the error along with the trace formatted as a Lisp-style
+// backtrace with one call per line.
+func (ee *EvalError) Backtrace() string {
+	var sb strings.Builder
+	sb.WriteString(ee.Error())
+	for _, frame := range ee.Trace {
+		sb.WriteString("\n  at ")
+		sb.WriteString(frame.String())
+	}
+
+	if ee.skipped > 0 {
+		sb.WriteString(fmt.Sprintf("\n  ... %d more", ee.skipped))
+	}
+	return sb.String()
+}
+
+func (ee *EvalError) Error() string {
+	if ee.IsZero() {
+		return ee.Cause.Error()
+	}
+
+	return fmt.Sprintf("%s: %v", ee.Position, ee.Cause)
+}
+
+func (frame StackFrame) String() string {
+	if frame.IsZero() {
+		return frame.Name
+	}
+
+	return fmt.Sprintf("%s (%s)", frame.Name, frame.Position)
+}
+
+// newEvalError returns err itself if it is an EvalError, or wraps err into
+// a new EvalError originating at pos. Origin of an existing EvalError is set
+// to pos if it is not known yet.
+func newEvalError(pos Position, err error) *EvalError {
+	if ee, ok := err.(*EvalError); ok {
+		if ee.IsZero() {
+			ee.Position = pos
+		}
+		return ee
+	}
+
+	return &EvalError{
+		Position: pos,
+		Cause:    err,
+	}
+}
+
+// recoverErr converts a panic value into an error and stores it in err. It
+// must be invoked directly using defer.
+func recoverErr(err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	if e, ok := v.(error); ok {
+		*err = e
+	} else {
+		*err = fmt.Errorf("panic: %v", v)
+	}
+}
+
+func (ee *EvalError) addFrame(frame StackFrame) {
+	if len(ee.Trace) >= maxTraceLen {
+		ee.skipped++
+		return
+	}
+
+	ee.Trace = append(ee.Trace, frame)
+}
+
+func frameName(lf List) string {
+	if sym, ok := lf.Forms[0].(Symbol); ok {
+		return sym.Value
+	}
+
+	name := []rune(lf.String())
+	if len(name) > maxFrameNameLen {
+		return string(name[:maxFrameNameLen]) + "..."
+	}
+	return string(name)
+}
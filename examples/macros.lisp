@@ -0,0 +1,21 @@
+; macros can be defined in lisp using defmacro and syntax-quote.
+(defmacro when-not [test & body]
+  `(cond
+     (~test nil)
+     (true (do ~@body))))
+
+; macroexpand-1 shows the form the macro expands to.
+(println (macroexpand-1 '(when-not false (println "hello"))))
+
+(when-not (== 1 2)
+  (println "1 is not 2"))
+
+; symbols ending with '#' are replaced with unique symbols to avoid
+; clashing with names used by the caller.
+(defmacro square-of [expr]
+  `(do
+     (label v# ~expr)
+     (* v# v#)))
+
+(println (macroexpand '(square-of (+ 1 2))))
+(println "square of 3 is" (square-of (+ 1 2)))
@@ -7,3 +7,19 @@
 
 ; finally call the factorial function
 (printf "10! = %f\n" (factorial 10))
+
+; calls in tail position do not grow the stack. so recursive
+; functions like below can run any number of iterations.
+(defn count-down [n]
+  (cond
+    ((< n 1) "done")
+    (true (count-down (- n 1)))))
+
+(println "count-down from 100000:" (count-down 100000))
+
+; loop/recur can be used for writing iterative algorithms.
+(println "sum of 1 to 100 ="
+  (loop [i 1 sum 0]
+    (cond
+      ((> i 100) sum)
+      (true (recur (+ i 1) (+ sum i))))))
@@ -48,3 +48,19 @@ calculate-λ                                                 ; symbol with non-A
 []                                                          ; empty vector
 [1 2 3 4]                                                   ; vector entries separated by space
 [1, 2, 3, 4]                                                ; vector entries can be separated by "," as well
+
+; maps -----------------------------------------------------------
+{}                                                          ; empty map
+{:name "parens" :version 1}                                 ; key-value pairs
+{:a 1, :b 2}                                                ; pairs can be separated by "," as well
+
+; dispatch macros ------------------------------------------------
+#{1 2 3}                                                    ; set literal
+#(+ % 1)                                                    ; anonymous function, same as (lambda [%1] (+ %1 1))
+#(* %1 %2)                                                  ; anonymous function with 2 arguments
+#_(this form is ignored)                                    ; discarded form
+#"[a-z]+\d*"                                                ; regular expression literal
+
+; syntax-quote ---------------------------------------------------
+`(x ~y)                                                     ; syntax-quote with unquote
+`(x ~@ys)                                                   ; unquote-splicing
@@ -1,7 +1,10 @@
 package parens
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"regexp"
 	"strings"
 )
 
@@ -10,6 +13,55 @@ import (
 // and the current scope.
 type MacroFunc func(scope Scope, exprs []Expr) (interface{}, error)
 
+// Expander can be implemented by values that act as macros by transforming
+// the un-evaluated forms into a new form. When a list with an Expander at
+// the head is evaluated, the expansion is evaluated in place of the list.
+// Unlike MacroFunc, expansion can be obtained without evaluating it.
+type Expander interface {
+	Expand(scope Scope, forms []Expr) (Expr, error)
+}
+
+// Invokable can be implemented by values that can be called from lisp
+// directly with the evaluated arguments instead of through reflection.
+// ctx is the context of the evaluation making the call.
+type Invokable interface {
+	Invoke(ctx context.Context, args ...interface{}) (interface{}, error)
+}
+
+// Realizer can be implemented by lazy collections. Realizers are realized
+// into a slice of items when passed to Go functions expecting a slice or
+// an array.
+type Realizer interface {
+	Realize(ctx context.Context) ([]interface{}, error)
+}
+
+// Position represents the location of a form in the source it was read
+// from. Line and Column point to the first rune of the form while EndLine
+// and EndColumn point to the last one. Lines and columns start from 1.
+type Position struct {
+	File      string
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+}
+
+// GetPos returns the position value itself. Forms embedding Position
+// expose their origin through this method.
+func (pos Position) GetPos() Position { return pos }
+
+// IsZero returns true if the position information is not available.
+func (pos Position) IsZero() bool { return pos.Line == 0 }
+
+func (pos Position) String() string {
+	file := pos.File
+	if file == "" {
+		file = "<unknown>"
+	}
+
+	return fmt.Sprintf("%s:%d:%d", file, pos.Line, pos.Column)
+}
+
 // Float64 represents double precision floating point numbers represented
 // using float or scientific number formats.
 type Float64 float64
@@ -60,74 +112,337 @@ func (kw Keyword) Eval(scope Scope) (interface{}, error) { return kw, nil }
 
 func (kw Keyword) String() string { return string(kw) }
 
+// Nil represents the nil literal.
+type Nil struct{}
+
+// Eval returns Go nil.
+func (Nil) Eval(scope Scope) (interface{}, error) { return nil, nil }
+
+func (Nil) String() string { return "nil" }
+
+// IsNil returns true if v is nil or a nil pointer, interface, map, slice,
+// func or channel. Such values returned from Go functions are same as the
+// nil in Lisp.
+func IsNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+
+	return false
+}
+
+// IsTruthy returns false if v is nil (as defined by IsNil) or false and
+// true otherwise. All conditionals use this to decide the branch to take.
+func IsTruthy(v interface{}) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+
+	return !IsNil(v)
+}
+
 // Symbol represents a name given to a value in memory.
-type Symbol string
+type Symbol struct {
+	Position
+	Value string
+}
 
-// Eval returns the value bound for the symbol in the scope.
+// Eval returns the value bound for the symbol in the scope. Qualified
+// symbols of the form ns/name evaluate to the value bound to name in the
+// Namespace (see LookupQualified).
 func (sym Symbol) Eval(scope Scope) (interface{}, error) {
-	return scope.Get(string(sym))
+	if val, ok, err := resolveQualified(scope, sym.Value); ok {
+		if err != nil {
+			return nil, newEvalError(sym.Position, err)
+		}
+		return val, nil
+	}
+
+	val, err := scope.Get(sym.Value)
+	if err != nil {
+		return nil, newEvalError(sym.Position, err)
+	}
+
+	return val, nil
 }
 
-func (sym Symbol) String() string { return string(sym) }
+func (sym Symbol) String() string { return sym.Value }
 
 // List represents an list of forms. Evaluating a list leads to a function
 // invocation.
-type List []Expr
+type List struct {
+	Position
+	Forms []Expr
+}
 
-// Eval executes the list as a function invocation.
+// Eval executes the list as a function invocation. Empty list evaluates to
+// nil.
 func (lf List) Eval(scope Scope) (interface{}, error) {
-	if len(lf) == 0 {
-		return lf, nil
+	if len(lf.Forms) == 0 {
+		return nil, nil
 	}
 
-	val, err := lf[0].Eval(scope)
-	if err != nil {
-		return nil, err
+	return lf.evalHead(scope, nil, false)
+}
+
+// EvalWithHead is same as Eval but uses head as the value of the first form
+// instead of evaluating it. It can be used by forms that need to inspect the
+// head before deciding how to evaluate the list.
+func (lf List) EvalWithHead(scope Scope, head interface{}) (interface{}, error) {
+	if len(lf.Forms) == 0 {
+		return nil, nil
+	}
+
+	return lf.evalHead(scope, head, true)
+}
+
+func (lf List) evalHead(scope Scope, head interface{}, resolved bool) (interface{}, error) {
+	ctx := ContextOf(scope)
+	if err := ctx.Err(); err != nil {
+		return nil, lf.wrapErr(err)
+	}
+
+	if lim := limiterOf(ctx); lim != nil {
+		if err := lim.enter(); err != nil {
+			return nil, lf.wrapErr(err)
+		}
+		defer lim.leave()
+	}
+
+	return lf.eval(ctx, scope, head, resolved)
+}
+
+func (lf List) eval(ctx context.Context, scope Scope, val interface{}, resolved bool) (interface{}, error) {
+	if !resolved {
+		if sym, ok := lf.Forms[0].(Symbol); ok && isMemberAccess(sym.Value) {
+			res, err := lf.evalMemberAccess(ctx, scope, sym.Value)
+			if err != nil {
+				return nil, lf.wrapErr(err)
+			}
+			return res, nil
+		}
+
+		var err error
+		if val, err = lf.Forms[0].Eval(scope); err != nil {
+			return nil, lf.wrapErr(err)
+		}
 	}
 
 	if macroFn, ok := val.(MacroFunc); ok {
-		return macroFn(scope, lf[1:])
+		res, err := callMacro(macroFn, scope, lf.Forms[1:])
+		if err != nil {
+			return nil, lf.wrapErr(err)
+		}
+		return res, nil
+	}
+
+	if expander, ok := val.(Expander); ok {
+		expanded, err := expandMacro(expander, scope, lf.Forms[1:])
+		if err != nil {
+			return nil, lf.wrapErr(err)
+		}
+
+		res, err := expanded.Eval(scope)
+		if err != nil {
+			return nil, lf.wrapErr(err)
+		}
+		return res, nil
 	}
 
 	args := []interface{}{}
-	for i := 1; i < len(lf); i++ {
-		arg, err := lf[i].Eval(scope)
+	for i := 1; i < len(lf.Forms); i++ {
+		arg, err := lf.Forms[i].Eval(scope)
 		if err != nil {
-			return nil, err
+			return nil, lf.wrapErr(err)
 		}
 		args = append(args, arg)
 	}
 
-	return reflectCall(val, args...)
+	res, err := call(ctx, val, args...)
+	if err != nil {
+		return nil, lf.wrapErr(err)
+	}
+
+	return res, nil
 }
 
-func (lf List) String() string { return containerString(lf, "(", ")", " ") }
+// wrapErr converts the error into an EvalError and records the list as a
+// frame in the trace.
+func (lf List) wrapErr(err error) error {
+	ee := newEvalError(lf.Position, err)
+	ee.addFrame(StackFrame{
+		Name:     frameName(lf),
+		Position: lf.Position,
+	})
+	return ee
+}
+
+func (lf List) String() string { return containerString(lf.Forms, "(", ")", " ") }
 
 // Vector represents a list of values. Unlike List type, evaluation of
 // vector does not lead to function invoke.
-type Vector []Expr
+type Vector struct {
+	Position
+	Forms []Expr
+}
+
+// Eval evaluates each item in the vector and returns the results as a
+// PersistentVector.
+func (vf Vector) Eval(scope Scope) (interface{}, error) {
+	vals, err := evalForms(scope, vf.Forms)
+	if err != nil {
+		return nil, newEvalError(vf.Position, err)
+	}
+
+	res := NewVector(vals...)
+	if err := checkAlloc(scope, res); err != nil {
+		return nil, newEvalError(vf.Position, err)
+	}
+
+	return res, nil
+}
+
+func (vf Vector) String() string { return containerString(vf.Forms, "[", "]", " ") }
 
-// Eval evaluates each item in the vector and returns the result list.
-func (vf Vector) Eval(scope Scope) (interface{}, error) { return evalForms(scope, vf) }
+// HashMap represents a map of key-value pairs. Keys and Values hold the
+// forms in the order they appear in the source.
+type HashMap struct {
+	Position
+	Keys   []Expr
+	Values []Expr
+}
 
-func (vf Vector) String() string { return containerString(vf, "[", "]", " ") }
+// Eval evaluates all the keys and values and returns the result as a
+// PersistentMap. Keys must evaluate to hashable values and must be unique.
+func (hm HashMap) Eval(scope Scope) (interface{}, error) {
+	res := (&PersistentMap{}).Transient()
+	for i := range hm.Keys {
+		key, err := hm.Keys[i].Eval(scope)
+		if err != nil {
+			return nil, newEvalError(hm.Position, err)
+		}
+
+		if _, err := Hash(key); err != nil {
+			return nil, newEvalError(hm.Position, fmt.Errorf("invalid map key: %w", err))
+		} else if _, found := res.Get(key); found {
+			return nil, newEvalError(hm.Position, fmt.Errorf("duplicate key '%v' in map", key))
+		}
+
+		val, err := hm.Values[i].Eval(scope)
+		if err != nil {
+			return nil, newEvalError(hm.Position, err)
+		}
+
+		if err := res.Assoc(key, val); err != nil {
+			return nil, newEvalError(hm.Position, err)
+		}
+	}
+
+	pm := res.Persistent()
+	if err := checkAlloc(scope, pm); err != nil {
+		return nil, newEvalError(hm.Position, err)
+	}
+
+	return pm, nil
+}
+
+func (hm HashMap) String() string {
+	parts := make([]Expr, 0, 2*len(hm.Keys))
+	for i := range hm.Keys {
+		parts = append(parts, hm.Keys[i], hm.Values[i])
+	}
+	return containerString(parts, "{", "}", " ")
+}
+
+// Set represents a collection of unique values.
+type Set struct {
+	Position
+	Forms []Expr
+}
+
+// Eval evaluates all the items and returns the result as a PersistentSet.
+// Items must evaluate to unique hashable values.
+func (set Set) Eval(scope Scope) (interface{}, error) {
+	res := (&PersistentSet{}).Transient()
+	for _, form := range set.Forms {
+		item, err := form.Eval(scope)
+		if err != nil {
+			return nil, newEvalError(set.Position, err)
+		}
+
+		if _, err := Hash(item); err != nil {
+			return nil, newEvalError(set.Position, fmt.Errorf("invalid set item: %w", err))
+		} else if res.Contains(item) {
+			return nil, newEvalError(set.Position, fmt.Errorf("duplicate item '%v' in set", item))
+		}
+
+		if err := res.Conj(item); err != nil {
+			return nil, newEvalError(set.Position, err)
+		}
+	}
+
+	ps := res.Persistent()
+	if err := checkAlloc(scope, ps); err != nil {
+		return nil, newEvalError(set.Position, err)
+	}
+
+	return ps, nil
+}
+
+func (set Set) String() string { return containerString(set.Forms, "#{", "}", " ") }
+
+// Regex represents a regular expression literal compiled by the reader.
+type Regex struct {
+	Value *regexp.Regexp
+}
+
+// Eval returns the compiled regular expression.
+func (re Regex) Eval(scope Scope) (interface{}, error) { return re.Value, nil }
+
+func (re Regex) String() string {
+	return fmt.Sprintf("#\"%s\"", strings.Replace(re.Value.String(), "\"", "\\\"", -1))
+}
 
 // Module represents a group of forms. Evaluating a module form returns the
 // result of evaluating the last form in the list.
 type Module []Expr
 
 // Eval evaluates all the forms and returns the result of the last evaluation.
+// Forms can use SwitchScope to change the scope used for evaluating the
+// forms following them.
 func (m Module) Eval(scope Scope) (interface{}, error) {
 	if len(m) == 0 {
 		return nil, nil
 	}
 
-	val, err := evalForms(scope, m)
-	if err != nil {
-		return nil, err
+	mod := &moduleState{}
+	ctx := context.WithValue(ContextOf(scope), moduleKey{}, mod)
+	scope = WithContext(ctx, scope)
+
+	var val interface{}
+	for _, form := range m {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		res, err := form.Eval(scope)
+		if err != nil {
+			return nil, err
+		}
+		val = res
+
+		if mod.next != nil {
+			scope, mod.next = WithContext(ctx, mod.next), nil
+		}
 	}
 
-	return val[len(val)-1], nil
+	return val, nil
 }
 
 func (m Module) String() string { return containerString(m, "", "", "\n") }
@@ -154,3 +469,32 @@ func evalForms(scope Scope, forms []Expr) ([]interface{}, error) {
 
 	return res, nil
 }
+
+// Call invokes the callable with the args. Callable can be an Invokable or
+// a Go function. Go functions are invoked the same way as when called from
+// Lisp (i.e., arguments are converted and ctx is passed if accepted).
+func Call(ctx context.Context, callable interface{}, args ...interface{}) (interface{}, error) {
+	return call(ctx, callable, args...)
+}
+
+// call invokes the value with given args directly if it is Invokable and
+// using reflection otherwise.
+func call(ctx context.Context, val interface{}, args ...interface{}) (res interface{}, err error) {
+	inv, ok := val.(Invokable)
+	if !ok {
+		return reflectCall(ctx, val, args...)
+	}
+
+	defer recoverErr(&err)
+	return inv.Invoke(ctx, args...)
+}
+
+func expandMacro(expander Expander, scope Scope, forms []Expr) (res Expr, err error) {
+	defer recoverErr(&err)
+	return expander.Expand(scope, forms)
+}
+
+func callMacro(macroFn MacroFunc, scope Scope, forms []Expr) (res interface{}, err error) {
+	defer recoverErr(&err)
+	return macroFn(scope, forms)
+}
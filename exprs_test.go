@@ -2,7 +2,7 @@ package parens_test
 
 import (
 	"github.com/spy16/parens"
-	"reflect"
+	"regexp"
 	"testing"
 )
 
@@ -30,6 +30,42 @@ func TestKeyword_Eval(t *testing.T) {
 	})
 }
 
+func TestNil_Eval(t *testing.T) {
+	testFormEval(t, evalTestCase{
+		form:     parens.Nil{},
+		getScope: nil,
+		want:     nil,
+	})
+}
+
+func TestIsTruthy(t *testing.T) {
+	var nilPtr *int
+	var nilErr error
+	var nilMap map[string]int
+
+	tests := []struct {
+		val  interface{}
+		want bool
+	}{
+		{val: nil, want: false},
+		{val: false, want: false},
+		{val: nilPtr, want: false},
+		{val: nilErr, want: false},
+		{val: nilMap, want: false},
+		{val: true, want: true},
+		{val: 0, want: true},
+		{val: "", want: true},
+		{val: []interface{}{}, want: true},
+		{val: new(int), want: true},
+	}
+
+	for _, tt := range tests {
+		if got := parens.IsTruthy(tt.val); got != tt.want {
+			t.Errorf("IsTruthy(%#v) = %t, want %t", tt.val, got, tt.want)
+		}
+	}
+}
+
 func TestCharacter_Eval(t *testing.T) {
 	testFormEval(t, evalTestCase{
 		form:     parens.Character('A'),
@@ -50,7 +86,7 @@ func TestSymbol_Eval(t *testing.T) {
 	testAllFormEval(t, []evalTestCase{
 		{
 			name: "WithBinding",
-			form: parens.Symbol("hello"),
+			form: parens.Symbol{Value: "hello"},
 			getScope: func() parens.Scope {
 				scope := parens.NewScope(nil)
 				_ = scope.Bind("hello", parens.Int64(10))
@@ -60,7 +96,7 @@ func TestSymbol_Eval(t *testing.T) {
 		},
 		{
 			name: "WithoutBinding",
-			form: parens.Symbol("non-existent-symbol"),
+			form: parens.Symbol{Value: "non-existent-symbol"},
 			getScope: func() parens.Scope {
 				scope := parens.NewScope(nil)
 				_ = scope.Bind("hello", parens.Int64(10))
@@ -76,42 +112,172 @@ func TestVector_Eval(t *testing.T) {
 	testAllFormEval(t, []evalTestCase{
 		{
 			name: "SimpleVector",
-			form: parens.Vector{
+			form: parens.Vector{Forms: []parens.Expr{
 				parens.Float64(1.3),
-			},
+			}},
 			getScope: nil,
-			want: []interface{}{
-				float64(1.3),
-			},
+			want:     parens.NewVector(float64(1.3)),
 		},
 		{
 			name: "VectorWithSymbol",
-			form: parens.Vector{
+			form: parens.Vector{Forms: []parens.Expr{
 				parens.Float64(1.3),
-				parens.Symbol("pi"),
-			},
+				parens.Symbol{Value: "pi"},
+			}},
 			getScope: func() parens.Scope {
 				scope := parens.NewScope(nil)
 				_ = scope.Bind("pi", parens.Float64(3.14))
 				return scope
 			},
-			want: []interface{}{
-				float64(1.3),
-				parens.Float64(3.14),
-			},
+			want: parens.NewVector(float64(1.3), parens.Float64(3.14)),
 		},
 		{
 			name: "VectorWithUnboundSymbol",
-			form: parens.Vector{
-				parens.Symbol("pi"),
-			},
+			form: parens.Vector{Forms: []parens.Expr{
+				parens.Symbol{Value: "pi"},
+			}},
 			getScope: func() parens.Scope { return parens.NewScope(nil) },
-			want:     []interface{}(nil),
+			want:     nil,
 			wantErr:  true,
 		},
 	})
 }
 
+func TestHashMap_Eval(t *testing.T) {
+	testAllFormEval(t, []evalTestCase{
+		{
+			name: "Empty",
+			form: parens.HashMap{},
+			want: mustMap(t),
+		},
+		{
+			name: "WithSymbols",
+			form: parens.HashMap{
+				Keys:   []parens.Expr{parens.Keyword(":pi"), parens.String("e")},
+				Values: []parens.Expr{parens.Symbol{Value: "pi"}, parens.Float64(2.718)},
+			},
+			getScope: func() parens.Scope {
+				scope := parens.NewScope(nil)
+				_ = scope.Bind("pi", 3.14)
+				return scope
+			},
+			want: mustMap(t, parens.Keyword(":pi"), 3.14, "e", 2.718),
+		},
+		{
+			name: "DuplicateKeys",
+			form: parens.HashMap{
+				Keys:   []parens.Expr{parens.Symbol{Value: "a"}, parens.String("hello")},
+				Values: []parens.Expr{parens.Int64(1), parens.Int64(2)},
+			},
+			getScope: func() parens.Scope {
+				scope := parens.NewScope(nil)
+				_ = scope.Bind("a", "hello")
+				return scope
+			},
+			wantErr: true,
+		},
+		{
+			name: "VectorKey",
+			form: parens.HashMap{
+				Keys:   []parens.Expr{parens.Vector{Forms: []parens.Expr{parens.Int64(1)}}},
+				Values: []parens.Expr{parens.Int64(1)},
+			},
+			want: mustMap(t, parens.NewVector(int64(1)), int64(1)),
+		},
+		{
+			name: "UnhashableKey",
+			form: parens.HashMap{
+				Keys:   []parens.Expr{parens.Symbol{Value: "s"}},
+				Values: []parens.Expr{parens.Int64(1)},
+			},
+			getScope: func() parens.Scope {
+				scope := parens.NewScope(nil)
+				_ = scope.Bind("s", []int{1})
+				return scope
+			},
+			wantErr: true,
+		},
+	})
+}
+
+func TestSet_Eval(t *testing.T) {
+	testAllFormEval(t, []evalTestCase{
+		{
+			name: "WithItems",
+			form: parens.Set{Forms: []parens.Expr{
+				parens.Int64(1),
+				parens.Symbol{Value: "a"},
+			}},
+			getScope: func() parens.Scope {
+				scope := parens.NewScope(nil)
+				_ = scope.Bind("a", "hello")
+				return scope
+			},
+			want: mustSet(t, int64(1), "hello"),
+		},
+		{
+			name: "DuplicateItems",
+			form: parens.Set{Forms: []parens.Expr{
+				parens.Symbol{Value: "a"},
+				parens.String("hello"),
+			}},
+			getScope: func() parens.Scope {
+				scope := parens.NewScope(nil)
+				_ = scope.Bind("a", "hello")
+				return scope
+			},
+			wantErr: true,
+		},
+	})
+}
+
+func TestRegex_Eval(t *testing.T) {
+	re := regexp.MustCompile(`\d+`)
+	testFormEval(t, evalTestCase{
+		form: parens.Regex{Value: re},
+		want: re,
+	})
+}
+
+func TestList_Eval(t *testing.T) {
+	testAllFormEval(t, []evalTestCase{
+		{
+			name:     "Empty",
+			form:     parens.List{},
+			getScope: nil,
+			want:     nil,
+		},
+		{
+			name: "FunctionCall",
+			form: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "inc"},
+				parens.Int64(1),
+			}},
+			getScope: func() parens.Scope {
+				scope := parens.NewScope(nil)
+				_ = scope.Bind("inc", func(i int64) int64 { return i + 1 })
+				return scope
+			},
+			want: int64(2),
+		},
+		{
+			name: "Expander",
+			form: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "swap"},
+				parens.Int64(1),
+				parens.Symbol{Value: "inc"},
+			}},
+			getScope: func() parens.Scope {
+				scope := parens.NewScope(nil)
+				_ = scope.Bind("inc", func(i int64) int64 { return i + 1 })
+				_ = scope.Bind("swap", swapExpander{})
+				return scope
+			},
+			want: int64(2),
+		},
+	})
+}
+
 func TestModule_Eval(t *testing.T) {
 	testAllFormEval(t, []evalTestCase{
 		{
@@ -139,7 +305,7 @@ func testFormEval(t *testing.T, tt evalTestCase) {
 		t.Errorf("Eval() error = %v, wantErr %v", err, tt.wantErr)
 		return
 	}
-	if !reflect.DeepEqual(got, tt.want) {
+	if !parens.Equal(got, tt.want) {
 		t.Errorf("Eval() got = %#v, want %#v", got, tt.want)
 	}
 }
@@ -154,6 +320,22 @@ func testAllFormEval(t *testing.T, tests []evalTestCase) {
 	}
 }
 
+func mustMap(t *testing.T, kvs ...interface{}) *parens.PersistentMap {
+	m, err := parens.NewMap(kvs...)
+	if err != nil {
+		t.Fatalf("NewMap() unexpected error: %v", err)
+	}
+	return m
+}
+
+func mustSet(t *testing.T, items ...interface{}) *parens.PersistentSet {
+	set, err := parens.NewSet(items...)
+	if err != nil {
+		t.Fatalf("NewSet() unexpected error: %v", err)
+	}
+	return set
+}
+
 type evalTestCase struct {
 	name     string
 	getScope func() parens.Scope
@@ -161,3 +343,10 @@ type evalTestCase struct {
 	want     interface{}
 	wantErr  bool
 }
+
+// swapExpander expands (swap a b) into (b a).
+type swapExpander struct{}
+
+func (swapExpander) Expand(scope parens.Scope, forms []parens.Expr) (parens.Expr, error) {
+	return parens.List{Forms: []parens.Expr{forms[1], forms[0]}}, nil
+}
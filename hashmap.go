@@ -0,0 +1,522 @@
+package parens
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+const hamtBits = 5
+
+// PersistentMap is an immutable hash map implemented as a hash array mapped
+// trie. Updates return a new map sharing the structure with the original
+// and take O(log32 n) time. Map literals evaluate to PersistentMap. Keys
+// are compared using Equal and must be hashable using Hash. The zero value
+// is an empty map.
+type PersistentMap struct {
+	cnt  int
+	root hamtNode
+}
+
+// NewMap returns a map of the key-value pairs in kvs.
+func NewMap(kvs ...interface{}) (*PersistentMap, error) {
+	if len(kvs)%2 != 0 {
+		return nil, fmt.Errorf("%w: expecting key-value pairs", ErrInvalidNumberOfArgs)
+	}
+
+	tm := (&PersistentMap{}).Transient()
+	for i := 0; i < len(kvs); i += 2 {
+		if err := tm.Assoc(kvs[i], kvs[i+1]); err != nil {
+			return nil, err
+		}
+	}
+	return tm.Persistent(), nil
+}
+
+// Len returns the number of entries in the map.
+func (pm *PersistentMap) Len() int { return pm.cnt }
+
+// Get returns the value mapped to the key. ok is false if the key is not
+// present.
+func (pm *PersistentMap) Get(key interface{}) (val interface{}, ok bool) {
+	h, err := Hash(key)
+	if err != nil || pm.root == nil {
+		return nil, false
+	}
+
+	return pm.root.find(0, h, key)
+}
+
+// Assoc returns a new map with the key mapped to the value.
+func (pm *PersistentMap) Assoc(key, val interface{}) (*PersistentMap, error) {
+	res := *pm
+	if err := res.assoc(nil, key, val); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Dissoc returns a new map without the key.
+func (pm *PersistentMap) Dissoc(key interface{}) *PersistentMap {
+	res := *pm
+	res.dissoc(nil, key)
+	return &res
+}
+
+// Range calls fn for each entry of the map in no particular order until fn
+// returns false.
+func (pm *PersistentMap) Range(fn func(key, val interface{}) bool) {
+	if pm.root != nil {
+		pm.root.each(fn)
+	}
+}
+
+// Keys returns the keys of the map in no particular order.
+func (pm *PersistentMap) Keys() []interface{} {
+	res := make([]interface{}, 0, pm.cnt)
+	pm.Range(func(key, _ interface{}) bool {
+		res = append(res, key)
+		return true
+	})
+	return res
+}
+
+// Vals returns the values of the map in no particular order.
+func (pm *PersistentMap) Vals() []interface{} {
+	res := make([]interface{}, 0, pm.cnt)
+	pm.Range(func(_, val interface{}) bool {
+		res = append(res, val)
+		return true
+	})
+	return res
+}
+
+// ToMap returns the entries as a new Go map. Keys are used as is and hence
+// persistent collections used as keys are compared by identity in the Go
+// map.
+func (pm *PersistentMap) ToMap() map[interface{}]interface{} {
+	res := make(map[interface{}]interface{}, pm.cnt)
+	pm.Range(func(key, val interface{}) bool {
+		res[key] = val
+		return true
+	})
+	return res
+}
+
+// Transient returns a transient copy of the map for batch updates.
+func (pm *PersistentMap) Transient() *TransientMap {
+	return &TransientMap{m: *pm, edit: &editToken{}}
+}
+
+func (pm *PersistentMap) String() string {
+	parts := make([]string, 0, pm.cnt)
+	pm.Range(func(key, val interface{}) bool {
+		parts = append(parts, fmt.Sprintf("%v %v", key, val))
+		return true
+	})
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func (pm *PersistentMap) assoc(edit *editToken, key, val interface{}) error {
+	h, err := Hash(key)
+	if err != nil {
+		return err
+	}
+
+	root := pm.root
+	if root == nil {
+		root = &bitmapNode{}
+	}
+
+	added := false
+	pm.root = root.assoc(edit, 0, h, key, val, &added)
+	if added {
+		pm.cnt++
+	}
+	return nil
+}
+
+func (pm *PersistentMap) dissoc(edit *editToken, key interface{}) {
+	h, err := Hash(key)
+	if err != nil || pm.root == nil {
+		return
+	}
+
+	removed := false
+	pm.root = pm.root.without(edit, 0, h, key, &removed)
+	if removed {
+		pm.cnt--
+	}
+}
+
+// TransientMap is a mutable copy of a PersistentMap used for making many
+// updates efficiently. Updates are made in place and the transient must
+// not be used after calling Persistent.
+type TransientMap struct {
+	m    PersistentMap
+	edit *editToken
+}
+
+// Len returns the number of entries in the map.
+func (tm *TransientMap) Len() int { return tm.m.cnt }
+
+// Get returns the value mapped to the key.
+func (tm *TransientMap) Get(key interface{}) (interface{}, bool) { return tm.m.Get(key) }
+
+// Assoc maps the key to the value.
+func (tm *TransientMap) Assoc(key, val interface{}) error {
+	tm.ensureEditable()
+	return tm.m.assoc(tm.edit, key, val)
+}
+
+// Dissoc removes the key.
+func (tm *TransientMap) Dissoc(key interface{}) {
+	tm.ensureEditable()
+	tm.m.dissoc(tm.edit, key)
+}
+
+// Persistent returns the map as a PersistentMap. The transient must not be
+// used afterwards.
+func (tm *TransientMap) Persistent() *PersistentMap {
+	tm.ensureEditable()
+	tm.edit = nil
+
+	res := tm.m
+	return &res
+}
+
+func (tm *TransientMap) ensureEditable() {
+	if tm.edit == nil {
+		panic("transient used after calling Persistent")
+	}
+}
+
+// PersistentSet is an immutable set backed by a PersistentMap. Set literals
+// evaluate to PersistentSet. The zero value is an empty set.
+type PersistentSet struct {
+	m PersistentMap
+}
+
+// NewSet returns a set of the items. Duplicate items are added only once.
+func NewSet(items ...interface{}) (*PersistentSet, error) {
+	ts := (&PersistentSet{}).Transient()
+	for _, item := range items {
+		if err := ts.Conj(item); err != nil {
+			return nil, err
+		}
+	}
+	return ts.Persistent(), nil
+}
+
+// Len returns the number of items in the set.
+func (ps *PersistentSet) Len() int { return ps.m.cnt }
+
+// Contains returns true if the item is in the set.
+func (ps *PersistentSet) Contains(item interface{}) bool {
+	_, found := ps.m.Get(item)
+	return found
+}
+
+// Conj returns a new set with the item added.
+func (ps *PersistentSet) Conj(item interface{}) (*PersistentSet, error) {
+	res := *ps
+	if err := res.m.assoc(nil, item, struct{}{}); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Disj returns a new set without the item.
+func (ps *PersistentSet) Disj(item interface{}) *PersistentSet {
+	res := *ps
+	res.m.dissoc(nil, item)
+	return &res
+}
+
+// Range calls fn for each item of the set in no particular order until fn
+// returns false.
+func (ps *PersistentSet) Range(fn func(item interface{}) bool) {
+	ps.m.Range(func(key, _ interface{}) bool { return fn(key) })
+}
+
+// ToSlice returns the items of the set as a Go slice in no particular
+// order.
+func (ps *PersistentSet) ToSlice() []interface{} { return ps.m.Keys() }
+
+// ToMap returns the items as keys of a new Go map.
+func (ps *PersistentSet) ToMap() map[interface{}]struct{} {
+	res := make(map[interface{}]struct{}, ps.m.cnt)
+	ps.Range(func(item interface{}) bool {
+		res[item] = struct{}{}
+		return true
+	})
+	return res
+}
+
+// Transient returns a transient copy of the set for batch updates.
+func (ps *PersistentSet) Transient() *TransientSet {
+	return &TransientSet{m: ps.m.Transient()}
+}
+
+func (ps *PersistentSet) String() string {
+	parts := make([]string, 0, ps.m.cnt)
+	ps.Range(func(item interface{}) bool {
+		parts = append(parts, fmt.Sprintf("%v", item))
+		return true
+	})
+	return "#{" + strings.Join(parts, " ") + "}"
+}
+
+// TransientSet is a mutable copy of a PersistentSet used for making many
+// updates efficiently. The transient must not be used after calling
+// Persistent.
+type TransientSet struct {
+	m *TransientMap
+}
+
+// Len returns the number of items in the set.
+func (ts *TransientSet) Len() int { return ts.m.Len() }
+
+// Contains returns true if the item is in the set.
+func (ts *TransientSet) Contains(item interface{}) bool {
+	_, found := ts.m.Get(item)
+	return found
+}
+
+// Conj adds the item to the set.
+func (ts *TransientSet) Conj(item interface{}) error { return ts.m.Assoc(item, struct{}{}) }
+
+// Disj removes the item from the set.
+func (ts *TransientSet) Disj(item interface{}) { ts.m.Dissoc(item) }
+
+// Persistent returns the set as a PersistentSet. The transient must not be
+// used afterwards.
+func (ts *TransientSet) Persistent() *PersistentSet {
+	return &PersistentSet{m: *ts.m.Persistent()}
+}
+
+// hamtNode is a node of the hash array mapped trie. Nodes owned by edit are
+// updated in place and copied otherwise. without returns nil if the node
+// becomes empty.
+type hamtNode interface {
+	find(shift uint, hash uint32, key interface{}) (interface{}, bool)
+	assoc(edit *editToken, shift uint, hash uint32, key, val interface{}, added *bool) hamtNode
+	without(edit *editToken, shift uint, hash uint32, key interface{}, removed *bool) hamtNode
+	each(fn func(key, val interface{}) bool) bool
+}
+
+// bitmapNode holds up to 32 entries indexed by 5 bits of the hash. Each
+// entry is either a key-value pair or a sub-node.
+type bitmapNode struct {
+	edit    *editToken
+	bitmap  uint32
+	entries []hamtEntry
+}
+
+type hamtEntry struct {
+	key, val interface{}
+	node     hamtNode
+}
+
+func (bn *bitmapNode) find(shift uint, hash uint32, key interface{}) (interface{}, bool) {
+	bit := bitFor(hash, shift)
+	if bn.bitmap&bit == 0 {
+		return nil, false
+	}
+
+	e := bn.entries[bn.index(bit)]
+	if e.node != nil {
+		return e.node.find(shift+hamtBits, hash, key)
+	} else if Equal(e.key, key) {
+		return e.val, true
+	}
+	return nil, false
+}
+
+func (bn *bitmapNode) assoc(edit *editToken, shift uint, hash uint32, key, val interface{}, added *bool) hamtNode {
+	bit := bitFor(hash, shift)
+	idx := bn.index(bit)
+
+	if bn.bitmap&bit == 0 {
+		res := bn.editable(edit)
+		res.entries = append(res.entries, hamtEntry{})
+		copy(res.entries[idx+1:], res.entries[idx:])
+		res.entries[idx] = hamtEntry{key: key, val: val}
+		res.bitmap |= bit
+		*added = true
+		return res
+	}
+
+	e := bn.entries[idx]
+	switch {
+	case e.node != nil:
+		node := e.node.assoc(edit, shift+hamtBits, hash, key, val, added)
+		if node == e.node {
+			return bn
+		}
+		e = hamtEntry{node: node}
+
+	case Equal(e.key, key):
+		e.val = val
+
+	default:
+		existingHash, _ := Hash(e.key)
+		e = hamtEntry{node: newHamtNode(edit, shift+hamtBits, e.key, e.val, existingHash, key, val, hash)}
+		*added = true
+	}
+
+	res := bn.editable(edit)
+	res.entries[idx] = e
+	return res
+}
+
+func (bn *bitmapNode) without(edit *editToken, shift uint, hash uint32, key interface{}, removed *bool) hamtNode {
+	bit := bitFor(hash, shift)
+	if bn.bitmap&bit == 0 {
+		return bn
+	}
+
+	idx := bn.index(bit)
+	e := bn.entries[idx]
+	if e.node != nil {
+		node := e.node.without(edit, shift+hamtBits, hash, key, removed)
+		if node == e.node {
+			return bn
+		} else if node != nil {
+			res := bn.editable(edit)
+			res.entries[idx] = hamtEntry{node: node}
+			return res
+		}
+	} else if !Equal(e.key, key) {
+		return bn
+	} else {
+		*removed = true
+	}
+
+	if bn.bitmap == bit {
+		return nil
+	}
+
+	res := bn.editable(edit)
+	res.entries = append(res.entries[:idx], res.entries[idx+1:]...)
+	res.bitmap &^= bit
+	return res
+}
+
+func (bn *bitmapNode) each(fn func(key, val interface{}) bool) bool {
+	for _, e := range bn.entries {
+		if e.node != nil {
+			if !e.node.each(fn) {
+				return false
+			}
+		} else if !fn(e.key, e.val) {
+			return false
+		}
+	}
+	return true
+}
+
+func (bn *bitmapNode) index(bit uint32) int {
+	return bits.OnesCount32(bn.bitmap & (bit - 1))
+}
+
+func (bn *bitmapNode) editable(edit *editToken) *bitmapNode {
+	if edit != nil && bn.edit == edit {
+		return bn
+	}
+
+	entries := make([]hamtEntry, len(bn.entries), len(bn.entries)+1)
+	copy(entries, bn.entries)
+	return &bitmapNode{edit: edit, bitmap: bn.bitmap, entries: entries}
+}
+
+// collisionNode holds the entries whose keys have the same hash.
+type collisionNode struct {
+	edit    *editToken
+	hash    uint32
+	entries []hamtEntry
+}
+
+func (cn *collisionNode) find(shift uint, hash uint32, key interface{}) (interface{}, bool) {
+	if idx := cn.index(key); idx >= 0 {
+		return cn.entries[idx].val, true
+	}
+	return nil, false
+}
+
+func (cn *collisionNode) assoc(edit *editToken, shift uint, hash uint32, key, val interface{}, added *bool) hamtNode {
+	if hash != cn.hash {
+		// nest the collision node in a bitmap node to separate the hashes.
+		bn := &bitmapNode{edit: edit, bitmap: bitFor(cn.hash, shift), entries: []hamtEntry{{node: cn}}}
+		return bn.assoc(edit, shift, hash, key, val, added)
+	}
+
+	res := cn.editable(edit)
+	if idx := cn.index(key); idx >= 0 {
+		res.entries[idx].val = val
+	} else {
+		res.entries = append(res.entries, hamtEntry{key: key, val: val})
+		*added = true
+	}
+	return res
+}
+
+func (cn *collisionNode) without(edit *editToken, shift uint, hash uint32, key interface{}, removed *bool) hamtNode {
+	idx := cn.index(key)
+	if idx < 0 {
+		return cn
+	}
+
+	*removed = true
+	if len(cn.entries) == 1 {
+		return nil
+	}
+
+	res := cn.editable(edit)
+	res.entries = append(res.entries[:idx], res.entries[idx+1:]...)
+	return res
+}
+
+func (cn *collisionNode) each(fn func(key, val interface{}) bool) bool {
+	for _, e := range cn.entries {
+		if !fn(e.key, e.val) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cn *collisionNode) index(key interface{}) int {
+	for i, e := range cn.entries {
+		if Equal(e.key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (cn *collisionNode) editable(edit *editToken) *collisionNode {
+	if edit != nil && cn.edit == edit {
+		return cn
+	}
+
+	entries := make([]hamtEntry, len(cn.entries), len(cn.entries)+1)
+	copy(entries, cn.entries)
+	return &collisionNode{edit: edit, hash: cn.hash, entries: entries}
+}
+
+// newHamtNode returns a node holding both the key-value pairs.
+func newHamtNode(edit *editToken, shift uint, k1, v1 interface{}, h1 uint32, k2, v2 interface{}, h2 uint32) hamtNode {
+	if h1 == h2 {
+		return &collisionNode{edit: edit, hash: h1, entries: []hamtEntry{{key: k1, val: v1}, {key: k2, val: v2}}}
+	}
+
+	added := false
+	var node hamtNode = &bitmapNode{edit: edit}
+	node = node.assoc(edit, shift, h1, k1, v1, &added)
+	return node.assoc(edit, shift, h2, k2, v2, &added)
+}
+
+func bitFor(hash uint32, shift uint) uint32 {
+	return 1 << ((hash >> shift) & 0x1f)
+}
@@ -0,0 +1,204 @@
+package parens_test
+
+import (
+	"testing"
+
+	"github.com/spy16/parens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistentMap(t *testing.T) {
+	t.Parallel()
+
+	const n = 5000
+
+	pm := &parens.PersistentMap{}
+	for i := 0; i < n; i++ {
+		var err error
+		pm, err = pm.Assoc(int64(i), i*2)
+		require.NoError(t, err)
+	}
+	require.Equal(t, n, pm.Len())
+
+	for i := 0; i < n; i++ {
+		v, found := pm.Get(int64(i))
+		require.True(t, found)
+		require.Equal(t, i*2, v)
+	}
+
+	_, found := pm.Get(int64(n))
+	assert.False(t, found)
+	_, found = pm.Get(int32(1))
+	assert.False(t, found, "keys of different types must not be equal")
+
+	smaller := pm
+	for i := 0; i < n; i += 2 {
+		smaller = smaller.Dissoc(int64(i))
+	}
+	assert.Equal(t, n/2, smaller.Len())
+	assert.Equal(t, n, pm.Len())
+
+	_, found = smaller.Get(int64(10))
+	assert.False(t, found)
+	v, found := smaller.Get(int64(11))
+	assert.True(t, found)
+	assert.Equal(t, 22, v)
+
+	assert.Equal(t, smaller.Len(), smaller.Dissoc("missing").Len())
+}
+
+func TestPersistentMap_Collisions(t *testing.T) {
+	t.Parallel()
+
+	// 1601 and 29651 have the same hash.
+	h1, _ := parens.Hash(int64(1601))
+	h2, _ := parens.Hash(int64(29651))
+	require.Equal(t, h1, h2)
+
+	pm, err := parens.NewMap(int64(1601), "a", int64(29651), "b", int64(1), "c")
+	require.NoError(t, err)
+	assert.Equal(t, 3, pm.Len())
+
+	v, _ := pm.Get(int64(1601))
+	assert.Equal(t, "a", v)
+	v, _ = pm.Get(int64(29651))
+	assert.Equal(t, "b", v)
+
+	pm2, err := pm.Assoc(int64(29651), "B")
+	require.NoError(t, err)
+	assert.Equal(t, 3, pm2.Len())
+	v, _ = pm2.Get(int64(29651))
+	assert.Equal(t, "B", v)
+	v, _ = pm.Get(int64(29651))
+	assert.Equal(t, "b", v)
+
+	pm3 := pm2.Dissoc(int64(1601))
+	assert.Equal(t, 2, pm3.Len())
+	_, found := pm3.Get(int64(1601))
+	assert.False(t, found)
+	v, _ = pm3.Get(int64(29651))
+	assert.Equal(t, "B", v)
+}
+
+func TestPersistentMap_Keys(t *testing.T) {
+	t.Parallel()
+
+	pm, err := parens.NewMap(parens.NewVector(int64(1), int64(2)), "vec", parens.Keyword(":a"), nil)
+	require.NoError(t, err)
+
+	v, found := pm.Get(parens.NewVector(int64(1), int64(2)))
+	assert.True(t, found)
+	assert.Equal(t, "vec", v)
+
+	v, found = pm.Get(parens.Keyword(":a"))
+	assert.True(t, found)
+	assert.Nil(t, v)
+
+	_, err = pm.Assoc([]int{1}, "slice")
+	assert.EqualError(t, err, "unhashable value of type '[]int'")
+
+	_, err = parens.NewMap(parens.Keyword(":a"))
+	assert.Error(t, err)
+}
+
+func TestTransientMap(t *testing.T) {
+	t.Parallel()
+
+	base, err := parens.NewMap("a", 1)
+	require.NoError(t, err)
+
+	tm := base.Transient()
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, tm.Assoc(int64(i), i))
+	}
+	tm.Dissoc("a")
+	assert.Equal(t, 1000, tm.Len())
+
+	pm := tm.Persistent()
+	assert.Equal(t, 1000, pm.Len())
+	assert.Equal(t, 1, base.Len())
+	assert.Panics(t, func() { _ = tm.Assoc("b", 2) })
+}
+
+func TestPersistentSet(t *testing.T) {
+	t.Parallel()
+
+	set, err := parens.NewSet(int64(1), "a", int64(1))
+	require.NoError(t, err)
+	assert.Equal(t, 2, set.Len())
+	assert.True(t, set.Contains("a"))
+	assert.False(t, set.Contains("b"))
+
+	bigger, err := set.Conj("b")
+	require.NoError(t, err)
+	assert.True(t, bigger.Contains("b"))
+	assert.False(t, set.Contains("b"))
+
+	smaller := bigger.Disj("a")
+	assert.False(t, smaller.Contains("a"))
+	assert.True(t, bigger.Contains("a"))
+
+	assert.Equal(t, map[interface{}]struct{}{int64(1): {}, "b": {}}, smaller.ToMap())
+
+	ts := smaller.Transient()
+	require.NoError(t, ts.Conj("c"))
+	ts.Disj(int64(1))
+	assert.Equal(t, map[interface{}]struct{}{"b": {}, "c": {}}, ts.Persistent().ToMap())
+}
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+
+	m1, _ := parens.NewMap(parens.Keyword(":a"), parens.NewVector(int64(1)), "b", int64(2))
+	m2, _ := parens.NewMap("b", int64(2), parens.Keyword(":a"), parens.NewVector(int64(1)))
+	m3, _ := parens.NewMap("b", int64(2))
+	s1, _ := parens.NewSet(int64(1), int64(2))
+	s2, _ := parens.NewSet(int64(2), int64(1))
+
+	table := []struct {
+		name string
+		a, b interface{}
+		want bool
+	}{
+		{name: "Nils", a: nil, b: nil, want: true},
+		{name: "NilAndValue", a: nil, b: int64(0), want: false},
+		{name: "Ints", a: int64(1), b: int64(1), want: true},
+		{name: "DifferentTypes", a: int64(1), b: float64(1), want: false},
+		{name: "Vectors", a: parens.NewVector(int64(1), "a"), b: parens.NewVector(int64(1), "a"), want: true},
+		{name: "VectorAndSlice", a: parens.NewVector(int64(1)), b: []int64{1}, want: true},
+		{name: "SliceAndVector", a: []interface{}{int64(1)}, b: parens.NewVector(int64(1)), want: true},
+		{name: "VectorsDifferentLen", a: parens.NewVector(int64(1)), b: parens.NewVector(int64(1), int64(2)), want: false},
+		{name: "Maps", a: m1, b: m2, want: true},
+		{name: "MapsDifferent", a: m1, b: m3, want: false},
+		{name: "Sets", a: s1, b: s2, want: true},
+		{name: "SetAndMap", a: s1, b: m3, want: false},
+		{name: "GoSlices", a: []int{1}, b: []int{1}, want: true},
+	}
+
+	for _, tt := range table {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parens.Equal(tt.a, tt.b))
+		})
+	}
+
+	h1, err := parens.Hash(m1)
+	require.NoError(t, err)
+	h2, err := parens.Hash(m2)
+	require.NoError(t, err)
+	assert.Equal(t, h1, h2)
+}
+
+func TestToGo(t *testing.T) {
+	t.Parallel()
+
+	set, _ := parens.NewSet("x")
+	pm, _ := parens.NewMap(parens.Keyword(":a"), parens.NewVector(int64(1), set))
+
+	want := map[interface{}]interface{}{
+		parens.Keyword(":a"): []interface{}{int64(1), map[interface{}]struct{}{"x": {}}},
+	}
+	assert.Equal(t, want, parens.ToGo(pm))
+	assert.Equal(t, "hello", parens.ToGo("hello"))
+}
@@ -0,0 +1,193 @@
+package parens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrNoSuchMember is returned when a field or method being accessed does
+// not exist on the value.
+var ErrNoSuchMember = errors.New("no such member")
+
+const (
+	methodPrefix = "."
+	fieldPrefix  = ".-"
+)
+
+// isMemberAccess returns true if the symbol is of the form '.Method' or
+// '.-Field' used for accessing members of Go values.
+func isMemberAccess(sym string) bool {
+	return len(sym) > len(methodPrefix) && strings.HasPrefix(sym, methodPrefix) &&
+		sym != fieldPrefix
+}
+
+// evalMemberAccess evaluates '(.Method target args...)' as a method call
+// and '(.-Field target)' as a field access.
+func (lf List) evalMemberAccess(ctx context.Context, scope Scope, member string) (_ interface{}, err error) {
+	defer recoverErr(&err)
+
+	if len(lf.Forms) < 2 {
+		return nil, fmt.Errorf("%s: target value is required", member)
+	}
+
+	vals, err := evalForms(scope, lf.Forms[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(member, fieldPrefix) {
+		if len(vals) != 1 {
+			return nil, fmt.Errorf("%s: %w: exactly 1 argument required, got %d",
+				member, ErrInvalidNumberOfArgs, len(vals))
+		}
+
+		field, err := GetField(vals[0], strings.TrimPrefix(member, fieldPrefix))
+		if err != nil {
+			return nil, err
+		}
+		return convertersOf(ctx).fromGo(field)
+	}
+
+	return CallMethod(ctx, vals[0], strings.TrimPrefix(member, methodPrefix), vals[1:]...)
+}
+
+// CallMethod invokes the named exported method of the target with the args.
+// Arguments are converted to the parameter types of the method the same way
+// as in function calls.
+func CallMethod(ctx context.Context, target interface{}, name string, args ...interface{}) (interface{}, error) {
+	if target == nil {
+		return nil, fmt.Errorf("cannot call method '%s' on nil", name)
+	}
+
+	rVal := reflect.ValueOf(target)
+	if !isExported(name) {
+		return nil, fmt.Errorf("method '%s' of '%s' is unexported", name, rVal.Type())
+	}
+
+	method := rVal.MethodByName(name)
+	if !method.IsValid() {
+		if _, found := reflect.PtrTo(rVal.Type()).MethodByName(name); found && rVal.Kind() != reflect.Ptr {
+			return nil, fmt.Errorf("method '%s' of '%s' requires a pointer receiver", name, rVal.Type())
+		}
+		return nil, fmt.Errorf("%w: method '%s' on '%s'", ErrNoSuchMember, name, rVal.Type())
+	}
+
+	return reflectCall(ctx, method.Interface(), args...)
+}
+
+// GetField returns the value of the named exported field of the target.
+// target must be a struct or a pointer to a struct.
+func GetField(target interface{}, name string) (interface{}, error) {
+	field, err := structField(target, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return field.Interface(), nil
+}
+
+// SetField sets the named exported field of the target to the value and
+// returns the value set. target must be a pointer to a struct. The value is
+// converted to the field type using the converters associated with ctx.
+func SetField(ctx context.Context, target interface{}, name string, v interface{}) (interface{}, error) {
+	if rVal := reflect.ValueOf(target); rVal.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("cannot set field '%s' of '%s': not a pointer", name, rVal.Type())
+	}
+
+	field, err := structField(target, name)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := convertValueType(ctx, v, field.Type())
+	if err != nil {
+		return nil, fmt.Errorf("cannot set field '%s': %v", name, err)
+	}
+
+	field.Set(val)
+	return v, nil
+}
+
+// NewInstance creates a new value of the given type and returns a pointer to it.
+// For struct types, fields can be initialized using name-value pairs in
+// the args where names are keywords or strings. Field values are converted
+// as in SetField. The new value is accounted using Allocate.
+func NewInstance(ctx context.Context, rType reflect.Type, args ...interface{}) (interface{}, error) {
+	ptr := reflect.New(rType)
+	if err := Allocate(ctx, ptr.Interface()); err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 {
+		return ptr.Interface(), nil
+	}
+
+	if rType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot initialize fields of non-struct type '%s'", rType)
+	} else if len(args)%2 != 0 {
+		return nil, fmt.Errorf("%w: expecting field name-value pairs", ErrInvalidNumberOfArgs)
+	}
+
+	for i := 0; i < len(args); i += 2 {
+		name, err := MemberName(args[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := SetField(ctx, ptr.Interface(), name, args[i+1]); err != nil {
+			return nil, err
+		}
+	}
+
+	return ptr.Interface(), nil
+}
+
+// MemberName returns the member name represented by a keyword (without the
+// leading ':') or a string.
+func MemberName(v interface{}) (string, error) {
+	switch name := v.(type) {
+	case Keyword:
+		return strings.TrimPrefix(string(name), ":"), nil
+
+	case string:
+		return name, nil
+
+	default:
+		return "", fmt.Errorf("member name must be a keyword or string, not '%s'",
+			reflect.TypeOf(v))
+	}
+}
+
+func structField(target interface{}, name string) (reflect.Value, error) {
+	rVal := reflect.ValueOf(target)
+	for rVal.Kind() == reflect.Ptr {
+		if rVal.IsNil() {
+			return reflect.Value{}, fmt.Errorf("cannot access field '%s' of nil", name)
+		}
+		rVal = rVal.Elem()
+	}
+
+	if rVal.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("cannot access field '%s' of non-struct type '%s'",
+			name, reflect.TypeOf(target))
+	}
+
+	sf, found := rVal.Type().FieldByName(name)
+	if !found {
+		return reflect.Value{}, fmt.Errorf("%w: field '%s' on '%s'", ErrNoSuchMember, name, rVal.Type())
+	} else if sf.PkgPath != "" {
+		return reflect.Value{}, fmt.Errorf("field '%s' of '%s' is unexported", name, rVal.Type())
+	}
+
+	return rVal.FieldByIndex(sf.Index), nil
+}
+
+func isExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
@@ -0,0 +1,134 @@
+package parens_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/spy16/parens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type point struct {
+	X, Y   int
+	hidden string
+}
+
+func (p point) Sum() int { return p.X + p.Y }
+
+func (p *point) Move(dx, dy int) *point {
+	p.X += dx
+	p.Y += dy
+	return p
+}
+
+func (p point) secret() string { return p.hidden }
+
+func TestMemberAccess(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		want    interface{}
+		wantErr string
+	}{
+		{
+			name: "Method",
+			src:  "(.Sum pt)",
+			want: 3,
+		},
+		{
+			name: "PointerMethodWithArgs",
+			src:  "(.-X (.Move pt 10 20))",
+			want: 11,
+		},
+		{
+			name: "Field",
+			src:  "(.-Y pt)",
+			want: 2,
+		},
+		{
+			name: "FieldOfValue",
+			src:  "(.-X val)",
+			want: 5,
+		},
+		{
+			name:    "PointerReceiverOnValue",
+			src:     "(.Move val 1 1)",
+			wantErr: "method 'Move' of 'parens_test.point' requires a pointer receiver",
+		},
+		{
+			name:    "UnexportedMethod",
+			src:     "(.secret pt)",
+			wantErr: "method 'secret' of '*parens_test.point' is unexported",
+		},
+		{
+			name:    "UnexportedField",
+			src:     "(.-hidden pt)",
+			wantErr: "field 'hidden' of 'parens_test.point' is unexported",
+		},
+		{
+			name:    "MissingMethod",
+			src:     "(.Foo pt)",
+			wantErr: "no such member: method 'Foo' on '*parens_test.point'",
+		},
+		{
+			name:    "MissingTarget",
+			src:     "(.Sum)",
+			wantErr: ".Sum: target value is required",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			scope.Bind("pt", &point{X: 1, Y: 2, hidden: "s"})
+			scope.Bind("val", point{X: 5})
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			if tt.wantErr != "" {
+				var evalErr *parens.EvalError
+				require.True(t, errors.As(err, &evalErr), "expected EvalError, got %v", err)
+				assert.Equal(t, tt.wantErr, evalErr.Cause.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewInstance(t *testing.T) {
+	t.Parallel()
+
+	got, err := parens.NewInstance(context.Background(), reflect.TypeOf(point{}), parens.Keyword(":X"), int64(3), "Y", 4.0)
+	require.NoError(t, err)
+	assert.Equal(t, &point{X: 3, Y: 4}, got)
+
+	_, err = parens.NewInstance(context.Background(), reflect.TypeOf(point{}), parens.Keyword(":hidden"), "x")
+	assert.EqualError(t, err, "field 'hidden' of 'parens_test.point' is unexported")
+
+	_, err = parens.NewInstance(context.Background(), reflect.TypeOf(point{}), parens.Keyword(":X"))
+	assert.True(t, errors.Is(err, parens.ErrInvalidNumberOfArgs))
+}
+
+func TestSetField(t *testing.T) {
+	t.Parallel()
+
+	pt := &point{}
+	got, err := parens.SetField(context.Background(), pt, "X", int64(10))
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), got)
+	assert.Equal(t, 10, pt.X)
+
+	_, err = parens.SetField(context.Background(), point{}, "X", int64(10))
+	assert.EqualError(t, err, "cannot set field 'X' of 'parens_test.point': not a pointer")
+
+	_, err = parens.SetField(context.Background(), pt, "X", "hello")
+	assert.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package parens
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"sync/atomic"
+)
+
+var (
+	// ErrStepLimit is returned when the evaluation exceeds the maximum
+	// number of steps allowed by Limits.
+	ErrStepLimit = errors.New("evaluation step limit exceeded")
+
+	// ErrDepthLimit is returned when nesting of calls exceeds the maximum
+	// depth allowed by Limits.
+	ErrDepthLimit = errors.New("call depth limit exceeded")
+
+	// ErrAllocLimit is returned when the total size of values created by
+	// the evaluation exceeds the maximum allowed by Limits.
+	ErrAllocLimit = errors.New("allocation limit exceeded")
+)
+
+type limiterKey struct{}
+
+// Options can be used to control the execution using ExecuteWithOptions.
+type Options struct {
+	// Context for the evaluation. context.Background() is used if nil.
+	Context context.Context
+
+	// Limits to be enforced during the evaluation.
+	Limits Limits
+
+	// Converters to be used for converting values passed to and returned
+	// from Go functions. DefaultConverters is used if nil.
+	Converters *Converters
+}
+
+// Limits represents caps on the resources an evaluation can consume. Zero
+// value for a limit means unlimited.
+type Limits struct {
+	// MaxSteps is the maximum number of lists (i.e., function and macro
+	// calls) that can be evaluated.
+	MaxSteps int64
+
+	// MaxDepth is the maximum nesting of list evaluations. Calls made in
+	// tail position of lisp functions do not increase the depth.
+	MaxDepth int64
+
+	// MaxAlloc is the maximum total size of values created by collection
+	// literals and by functions that report the values they create using
+	// Allocate. Values that are only passed around are not counted again.
+	// Size of strings is the number of bytes, that of collections is the
+	// number of items and that of structs is the number of fields.
+	MaxAlloc int64
+}
+
+// ExecuteWithOptions is same as ExecuteContext but uses the context and
+// enforces the limits from the options. When a limit is exceeded, the
+// returned error wraps one of ErrStepLimit, ErrDepthLimit or ErrAllocLimit
+// and can be checked using errors.Is.
+func ExecuteWithOptions(rd io.Reader, env Scope, opts Options) (interface{}, error) {
+	expr, err := Parse(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	return ExecuteExprWithOptions(expr, env, opts)
+}
+
+// ExecuteExprWithOptions is same as ExecuteWithOptions but executes the
+// already parsed expr.
+func ExecuteExprWithOptions(expr Expr, env Scope, opts Options) (interface{}, error) {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if opts.Limits != (Limits{}) {
+		ctx = context.WithValue(ctx, limiterKey{}, &limiter{Limits: opts.Limits})
+	}
+
+	if opts.Converters != nil {
+		ctx = context.WithValue(ctx, convertersKey{}, opts.Converters)
+	}
+
+	return ExecuteExprContext(ctx, expr, env)
+}
+
+// Step checks the context and accounts one evaluation step against the
+// limits associated with ctx, if any. Evaluations that repeat without going
+// through List.Eval (e.g., tail calls executed iteratively) must call Step
+// for every iteration.
+func Step(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if lim := limiterOf(ctx); lim != nil {
+		if steps := atomic.AddInt64(&lim.steps, 1); lim.MaxSteps > 0 && steps > lim.MaxSteps {
+			return ErrStepLimit
+		}
+	}
+
+	return nil
+}
+
+// LimitsOf returns the limits enforced on the evaluation using ctx. Zero
+// value is returned if the evaluation is not limited.
+func LimitsOf(ctx context.Context) Limits {
+	if lim := limiterOf(ctx); lim != nil {
+		return lim.Limits
+	}
+
+	return Limits{}
+}
+
+// Allocate accounts the size of a newly created value against the limits
+// associated with ctx, if any. Go functions that create strings, collections
+// or structs (e.g., a constructor) should call Allocate with the result so
+// that it counts towards Limits.MaxAlloc.
+func Allocate(ctx context.Context, v interface{}) error {
+	if lim := limiterOf(ctx); lim != nil {
+		return lim.allocated(v)
+	}
+
+	return nil
+}
+
+// limiter tracks the resource usage of an evaluation against the limits.
+type limiter struct {
+	Limits
+
+	steps, depth, alloc int64
+}
+
+func limiterOf(ctx context.Context) *limiter {
+	lim, _ := ctx.Value(limiterKey{}).(*limiter)
+	return lim
+}
+
+// enter must be called before evaluating a list. If the call succeeds, leave
+// must be called once the evaluation is done.
+func (lim *limiter) enter() error {
+	if steps := atomic.AddInt64(&lim.steps, 1); lim.MaxSteps > 0 && steps > lim.MaxSteps {
+		return ErrStepLimit
+	}
+
+	if depth := atomic.AddInt64(&lim.depth, 1); lim.MaxDepth > 0 && depth > lim.MaxDepth {
+		atomic.AddInt64(&lim.depth, -1)
+		return ErrDepthLimit
+	}
+
+	return nil
+}
+
+func (lim *limiter) leave() { atomic.AddInt64(&lim.depth, -1) }
+
+// allocated accounts the size of the value created by the evaluation.
+func (lim *limiter) allocated(v interface{}) error {
+	if lim.MaxAlloc <= 0 {
+		return nil
+	}
+
+	size := int64(sizeOf(v))
+	if size > 0 && atomic.AddInt64(&lim.alloc, size) > lim.MaxAlloc {
+		return ErrAllocLimit
+	}
+
+	return nil
+}
+
+func sizeOf(v interface{}) int {
+	if v == nil {
+		return 0
+	}
+
+	switch c := v.(type) {
+	case *PersistentVector:
+		return c.Len()
+
+	case *PersistentMap:
+		return c.Len()
+
+	case *PersistentSet:
+		return c.Len()
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len()
+
+	case reflect.Struct:
+		return rv.NumField()
+	}
+
+	return 0
+}
+
+// checkAlloc accounts the size of the value against the limits associated
+// with the scope, if any.
+func checkAlloc(scope Scope, v interface{}) error {
+	return Allocate(ContextOf(scope), v)
+}
@@ -0,0 +1,138 @@
+package parens
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteWithOptions_Limits(t *testing.T) {
+	t.Parallel()
+
+	newScope := func() Scope {
+		scope := NewScope(nil)
+		scope.Bind("noop", func() {})
+		scope.Bind("repeat", func(ctx context.Context, s string, n int64) (string, error) {
+			res := strings.Repeat(s, int(n))
+			return res, Allocate(ctx, res)
+		})
+		scope.Bind("identity", func(v interface{}) interface{} { return v })
+		scope.Bind("forever", MacroFunc(func(scope Scope, exprs []Expr) (interface{}, error) {
+			for {
+				if _, err := exprs[0].Eval(scope); err != nil {
+					return nil, err
+				}
+			}
+		}))
+		scope.Bind("nest", MacroFunc(func(scope Scope, exprs []Expr) (interface{}, error) {
+			return exprs[0].Eval(scope)
+		}))
+		scope.Bind("recurse", MacroFunc(func(scope Scope, exprs []Expr) (interface{}, error) {
+			return List{Forms: []Expr{Symbol{Value: "recurse"}}}.Eval(scope)
+		}))
+		return scope
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		limits  Limits
+		want    interface{}
+		wantErr error
+	}{
+		{
+			name:    "StepLimit",
+			src:     "(forever (noop))",
+			limits:  Limits{MaxSteps: 100},
+			wantErr: ErrStepLimit,
+		},
+		{
+			name:    "DepthLimit",
+			src:     "(recurse)",
+			limits:  Limits{MaxDepth: 50},
+			wantErr: ErrDepthLimit,
+		},
+		{
+			name:    "AllocLimitString",
+			src:     `(repeat "a" 100)`,
+			limits:  Limits{MaxAlloc: 50},
+			wantErr: ErrAllocLimit,
+		},
+		{
+			name:    "AllocLimitVector",
+			src:     `[(repeat "a" 10) [1 2 3]]`,
+			limits:  Limits{MaxAlloc: 14},
+			wantErr: ErrAllocLimit,
+		},
+		{
+			name:   "PassThroughNotCounted",
+			src:    `(identity (identity (identity (nest (repeat "a" 10)))))`,
+			limits: Limits{MaxAlloc: 10},
+			want:   "aaaaaaaaaa",
+		},
+		{
+			name:   "WithinLimits",
+			src:    `(nest (nest (repeat "a" 3)))`,
+			limits: Limits{MaxSteps: 3, MaxDepth: 3, MaxAlloc: 10},
+			want:   "aaa",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExecuteWithOptions(strings.NewReader(tt.src), newScope(), Options{
+				Limits: tt.limits,
+			})
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "expected %v, got %v", tt.wantErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExecuteWithOptions_Context(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	scope := NewScope(nil)
+	scope.Bind("noop", func() {})
+	scope.Bind("forever", MacroFunc(func(scope Scope, exprs []Expr) (interface{}, error) {
+		for {
+			if _, err := exprs[0].Eval(scope); err != nil {
+				return nil, err
+			}
+		}
+	}))
+
+	_, err := ExecuteWithOptions(strings.NewReader("(forever (noop))"), scope, Options{
+		Context: ctx,
+		Limits:  Limits{MaxDepth: 10},
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestEvalError_TraceLimit(t *testing.T) {
+	scope := NewScope(nil)
+	scope.Bind("recurse", MacroFunc(func(scope Scope, exprs []Expr) (interface{}, error) {
+		return List{Forms: []Expr{Symbol{Value: "recurse"}}}.Eval(scope)
+	}))
+
+	_, err := ExecuteWithOptions(strings.NewReader("(recurse)"), scope, Options{
+		Limits: Limits{MaxDepth: 100},
+	})
+
+	var evalErr *EvalError
+	require.True(t, errors.As(err, &evalErr))
+	assert.Len(t, evalErr.Trace, maxTraceLen)
+	assert.Contains(t, evalErr.Backtrace(), "... 37 more")
+}
@@ -0,0 +1,162 @@
+package parens
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var packages = struct {
+	mu      sync.RWMutex
+	members map[string]map[string]interface{}
+}{members: map[string]map[string]interface{}{}}
+
+// NamespacesName is the name under which a NamespaceResolver can be bound
+// in a scope. Qualified symbols ns/name whose ns is not bound in the scope
+// are resolved using it.
+const NamespacesName = "*namespaces*"
+
+type moduleKey struct{}
+
+// NamespaceResolver is implemented by registries of namespaces (e.g., the
+// stdlib.ModuleRegistry) for resolving namespaces by their full name.
+type NamespaceResolver interface {
+	ResolveNamespace(name string) (*Namespace, bool)
+}
+
+// Namespace is a named scope for the definitions of a module. Namespaces
+// can be bound in other scopes like any other value (usually under an
+// alias) and the names defined in a namespace are then accessible using
+// qualified symbols of the form alias/name. Namespace is safe for
+// concurrent use.
+type Namespace struct {
+	*concurrentScope
+	name string
+}
+
+// NewNamespace returns an empty namespace with the given name. parent is
+// usually the scope with the core functions and can be nil.
+func NewNamespace(name string, parent Scope) *Namespace {
+	return &Namespace{
+		name: name,
+		concurrentScope: &concurrentScope{
+			defaultScope: defaultScope{
+				parent: parent,
+				vals:   map[string]scopeEntry{},
+			},
+		},
+	}
+}
+
+// Name returns the name of the namespace.
+func (ns *Namespace) Name() string { return ns.name }
+
+// Root returns the namespace itself so that global definitions made by the
+// code of the namespace do not leak into the parent scope.
+func (ns *Namespace) Root() Scope { return ns }
+
+// Own returns the value bound to name in the namespace itself. Unlike Get,
+// parent scopes are not consulted.
+func (ns *Namespace) Own(name string) (interface{}, error) {
+	ns.mu.RLock()
+	entry := ns.entry(name)
+	ns.mu.RUnlock()
+
+	if entry == nil {
+		return nil, fmt.Errorf("name '%s' not found in namespace '%s'", name, ns.name)
+	} else if !entry.val.RVal.IsValid() {
+		return nil, nil
+	}
+	return entry.val.RVal.Interface(), nil
+}
+
+func (ns *Namespace) String() string { return fmt.Sprintf("<namespace: %s>", ns.name) }
+
+// RegisterPackage registers the members of a Go package (functions, types
+// as reflect.Type, constants etc.) under the given name so that modules can
+// require it like a namespace (e.g., (require 'strings)). Registering again
+// with the same name replaces the members. RegisterPackage is usually called
+// from the init function generated by cmd/parens-gen.
+func RegisterPackage(name string, members map[string]interface{}) {
+	copied := make(map[string]interface{}, len(members))
+	for k, v := range members {
+		copied[k] = v
+	}
+
+	packages.mu.Lock()
+	defer packages.mu.Unlock()
+	packages.members[name] = copied
+}
+
+// LookupPackage returns the members of the Go package registered with the
+// given name using RegisterPackage.
+func LookupPackage(name string) (members map[string]interface{}, found bool) {
+	packages.mu.RLock()
+	defer packages.mu.RUnlock()
+
+	members, found = packages.members[name]
+	return members, found
+}
+
+// SwitchScope makes target the scope for evaluating the remaining top-level
+// forms of the module being evaluated (e.g., for switching into the
+// namespace declared at the beginning of a file). Error is returned if the
+// scope does not belong to the evaluation of a module.
+func SwitchScope(scope Scope, target Scope) error {
+	mod, _ := ContextOf(scope).Value(moduleKey{}).(*moduleState)
+	if mod == nil {
+		return errors.New("not evaluating a module")
+	}
+
+	mod.next = target
+	return nil
+}
+
+// moduleState is shared by the forms of a module being evaluated.
+type moduleState struct {
+	next Scope
+}
+
+// LookupQualified splits the qualified symbol of the form ns/name and
+// returns the namespace for ns. ns can be an alias bound to a namespace in
+// the scope or the full name of a namespace known to the NamespaceResolver
+// bound in the scope. ok is false if the symbol is not qualified or ns is
+// not a namespace.
+func LookupQualified(scope Scope, sym string) (ns *Namespace, name string, ok bool) {
+	idx := strings.IndexRune(sym, '/')
+	if idx <= 0 || idx == len(sym)-1 {
+		return nil, "", false
+	}
+
+	alias, name := sym[:idx], sym[idx+1:]
+	if v, err := scope.Get(alias); err == nil {
+		ns, ok = v.(*Namespace)
+		return ns, name, ok
+	}
+
+	v, err := scope.Get(NamespacesName)
+	if err != nil {
+		return nil, "", false
+	}
+
+	resolver, isResolver := v.(NamespaceResolver)
+	if !isResolver {
+		return nil, "", false
+	}
+
+	ns, ok = resolver.ResolveNamespace(alias)
+	return ns, name, ok
+}
+
+// resolveQualified resolves symbols of the form ns/name using LookupQualified.
+// ok is false if the symbol does not refer to a namespace.
+func resolveQualified(scope Scope, sym string) (val interface{}, ok bool, err error) {
+	ns, name, ok := LookupQualified(scope, sym)
+	if !ok {
+		return nil, false, nil
+	}
+
+	val, err = ns.Own(name)
+	return val, true, err
+}
@@ -0,0 +1,87 @@
+package parens
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespace(t *testing.T) {
+	t.Parallel()
+
+	core := NewScope(nil)
+	core.Bind("x", "core")
+
+	ns := NewNamespace("util", core)
+	ns.Bind("y", "util")
+	assert.Equal(t, "util", ns.Name())
+	assert.Equal(t, "<namespace: util>", ns.String())
+
+	val, err := ns.Get("x")
+	assert.NoError(t, err)
+	assert.Equal(t, "core", val)
+
+	_, err = ns.Own("x")
+	assert.EqualError(t, err, "name 'x' not found in namespace 'util'")
+
+	scope := NewScope(core)
+	scope.Bind("u", ns)
+
+	val, err = ExecuteStr("u/y", scope)
+	assert.NoError(t, err)
+	assert.Equal(t, "util", val)
+
+	_, err = ExecuteStr("u/x", scope)
+	assert.Error(t, err, "names in parent of a namespace must not be accessible")
+
+	_, err = ExecuteStr("x/y", scope)
+	assert.Error(t, err)
+}
+
+func TestSwitchScope(t *testing.T) {
+	t.Parallel()
+
+	scope := NewScope(nil)
+	ns := NewNamespace("app", scope)
+
+	scope.Bind("switch", MacroFunc(func(scope Scope, _ []Expr) (interface{}, error) {
+		return nil, SwitchScope(scope, ns)
+	}))
+	scope.Bind("def", MacroFunc(func(scope Scope, exprs []Expr) (interface{}, error) {
+		return nil, scope.Bind(exprs[0].(Symbol).Value, true)
+	}))
+
+	_, err := ExecuteStr(`(def before) (switch) (def after)`, scope)
+	require.NoError(t, err)
+
+	_, err = scope.Get("before")
+	assert.NoError(t, err)
+	_, err = scope.Get("after")
+	assert.Error(t, err, "forms after switch must be evaluated in the namespace")
+	_, err = ns.Own("after")
+	assert.NoError(t, err)
+
+	_, err = ExecuteStr(`(def next)`, scope)
+	require.NoError(t, err)
+	_, err = scope.Get("next")
+	assert.NoError(t, err, "switch must not affect other evaluations")
+
+	assert.EqualError(t, SwitchScope(scope, ns), "not evaluating a module")
+}
+
+func TestRegisterPackage(t *testing.T) {
+	t.Parallel()
+
+	members := map[string]interface{}{"Upper": strings.ToUpper}
+	RegisterPackage("test.strings", members)
+	members["Lower"] = strings.ToLower
+
+	got, found := LookupPackage("test.strings")
+	assert.True(t, found)
+	assert.Len(t, got, 1, "changes after registration must not be visible")
+
+	_, found = LookupPackage("test.missing")
+	assert.False(t, found)
+}
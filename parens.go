@@ -1,7 +1,6 @@
 package parens
 
 import (
-	"fmt"
 	"io"
 	"strings"
 )
@@ -22,27 +21,20 @@ func Execute(rd io.Reader, env Scope) (interface{}, error) {
 	return ExecuteExpr(expr, env)
 }
 
-// ExecuteExpr executes the expr in the given scope.
+// ExecuteExpr executes the expr in the given scope. Evaluation failures and
+// panics are returned as *EvalError.
 func ExecuteExpr(expr Expr, env Scope) (interface{}, error) {
 	var res interface{}
 	var evalErr error
 	safeWrapper := func() {
-		defer func() {
-			if v := recover(); v != nil {
-				if err, ok := v.(error); ok {
-					evalErr = err
-				} else {
-					evalErr = fmt.Errorf("panic: %v", v)
-				}
-			}
-		}()
+		defer recoverErr(&evalErr)
 
 		res, evalErr = expr.Eval(env)
 	}
 
 	safeWrapper()
 	if evalErr != nil {
-		return nil, evalErr
+		return nil, newEvalError(Position{}, evalErr)
 	}
 
 	return res, nil
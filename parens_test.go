@@ -1,6 +1,7 @@
 package parens
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -20,12 +21,13 @@ func BenchmarkParens_Execute(suite *testing.B) {
 		}
 	})
 
-	expr := List(
-		[]Expr{
-			Symbol("add"),
+	expr := List{
+		Forms: []Expr{
+			Symbol{Value: "add"},
 			Int64(1),
 			Int64(2),
-		})
+		},
+	}
 
 	suite.Run("ExecuteExpr", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
@@ -72,3 +74,49 @@ func TestExecute_EvalFailure(t *testing.T) {
 	require.Error(t, err)
 	assert.Nil(t, res)
 }
+
+func TestExecute_ErrorPosition(t *testing.T) {
+	scope := NewScope(nil)
+	scope.Bind("add", add)
+
+	_, err := ExecuteStr("(add 1\n  (add x 2))", scope)
+	require.Error(t, err)
+	assert.Equal(t, "<string>:2:8: name 'x' not found", err.Error())
+
+	var evalErr *EvalError
+	require.True(t, errors.As(err, &evalErr))
+	assert.Equal(t, 2, evalErr.Line)
+	assert.Equal(t, 8, evalErr.Column)
+
+	_, err = ExecuteStr("(add 1 \"hello\")", scope)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "<string>:1:1: ")
+}
+
+func TestExecute_EvalErrorTrace(t *testing.T) {
+	scope := NewScope(nil)
+	scope.Bind("add", add)
+	scope.Bind("call", MacroFunc(func(scope Scope, exprs []Expr) (interface{}, error) {
+		return exprs[0].Eval(scope)
+	}))
+	scope.Bind("explode", func() { panic("boom") })
+
+	_, err := ExecuteStr("(call\n  (add 1 (add x 2)))", scope)
+	var evalErr *EvalError
+	require.True(t, errors.As(err, &evalErr))
+
+	names := []string{}
+	for _, frame := range evalErr.Trace {
+		names = append(names, frame.Name)
+	}
+	assert.Equal(t, []string{"add", "add", "call"}, names)
+	assert.Equal(t, "<string>:2:15: name 'x' not found\n"+
+		"  at add (<string>:2:10)\n"+
+		"  at add (<string>:2:3)\n"+
+		"  at call (<string>:1:1)", evalErr.Backtrace())
+
+	_, err = ExecuteStr("(call (explode))", scope)
+	require.True(t, errors.As(err, &evalErr))
+	assert.Equal(t, "<string>:1:7: panic: boom", evalErr.Error())
+	assert.Len(t, evalErr.Trace, 2)
+}
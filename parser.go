@@ -46,16 +46,17 @@ var (
 )
 
 // New returns a lisp reader instance which can read forms from r. Reader
-// behavior can be customized by using SetMacro to override or remove from
-// the default read table. File name will be inferred from the reader value
-// and type information.
+// behavior can be customized by using SetMacro and SetDispatchMacro to
+// override or remove from the default read and dispatch tables. File name
+// will be inferred from the reader value and type information.
 func New(rs io.Reader) *Reader {
 	rd := &Reader{
 		Stream: Stream{
 			File: inferFileName(rs),
 			rs:   bufio.NewReader(rs),
 		},
-		macros: defaultReadTable(),
+		macros:   defaultReadTable(),
+		dispatch: defaultDispatchTable(),
 	}
 
 	return rd
@@ -93,8 +94,10 @@ type Scope interface {
 type Reader struct {
 	Stream
 
-	Hook   ReaderMacro
-	macros map[rune]ReaderMacro
+	Hook     ReaderMacro
+	macros   map[rune]ReaderMacro
+	dispatch map[rune]ReaderMacro
+	inAnonFn bool
 }
 
 // All consumes characters from stream until EOF and returns a list of all the
@@ -139,9 +142,13 @@ func (rd *Reader) One() (Expr, error) {
 }
 
 // IsTerminal returns true if the rune should terminate a form. ReaderMacro
-// trigger runes defined in the read table and all space characters including
-// "," are considered terminal.
+// trigger runes defined in the read table (except the dispatch trigger "#")
+// and all space characters including "," are considered terminal.
 func (rd *Reader) IsTerminal(r rune) bool {
+	if r == dispatchTrigger {
+		return false
+	}
+
 	_, found := rd.macros[r]
 	return found || isSpace(r)
 }
@@ -158,6 +165,20 @@ func (rd *Reader) SetMacro(init rune, macro ReaderMacro) {
 	rd.macros[init] = macro
 }
 
+// SetDispatchMacro sets the given reader macro as the handler for the
+// dispatch rune in the dispatch table. Dispatch macros are invoked when the
+// dispatch rune follows "#" (e.g., '{' for "#{"). The macro receives the
+// dispatch rune as init. If the macro value given is nil, entry for the
+// dispatch rune will be removed from the dispatch table.
+func (rd *Reader) SetDispatchMacro(dispatch rune, macro ReaderMacro) {
+	if macro == nil {
+		delete(rd.dispatch, dispatch)
+		return
+	}
+
+	rd.dispatch[dispatch] = macro
+}
+
 // readOne is same as One() but always returns un-annotated errors.
 func (rd *Reader) readOne() (Expr, error) {
 	if err := rd.SkipSpaces(); err != nil {
@@ -169,6 +190,18 @@ func (rd *Reader) readOne() (Expr, error) {
 		return nil, err
 	}
 
+	start := rd.position()
+	form, err := rd.readForm(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return withPosition(form, start, rd.position()), nil
+}
+
+// readForm reads the form beginning with init rune using the number reader,
+// a macro from read table or the hook, whichever is applicable.
+func (rd *Reader) readForm(r rune) (Expr, error) {
 	if unicode.IsNumber(r) {
 		return readNumber(rd, r)
 	} else if r == '+' || r == '-' {
@@ -200,6 +233,15 @@ func (rd *Reader) readOne() (Expr, error) {
 	return readSymbol(rd, r)
 }
 
+func (rd *Reader) position() Position {
+	file, line, col := rd.Info()
+	return Position{
+		File:   file,
+		Line:   line,
+		Column: col,
+	}
+}
+
 func (rd *Reader) annotateErr(e error) error {
 	if e == io.EOF || e == ErrSkip {
 		return e
@@ -257,7 +299,11 @@ func readSymbol(rd *Reader, init rune) (Expr, error) {
 		return nil, err
 	}
 
-	return Symbol(s), nil
+	if s == "nil" {
+		return Nil{}, nil
+	}
+
+	return Symbol{Value: s}, nil
 }
 
 func readKeyword(rd *Reader, init rune) (Expr, error) {
@@ -356,7 +402,7 @@ func readList(rd *Reader, _ rune) (Expr, error) {
 		return nil, err
 	}
 
-	return List(forms), nil
+	return List{Forms: forms}, nil
 }
 
 func readVector(rd *Reader, _ rune) (Expr, error) {
@@ -365,7 +411,35 @@ func readVector(rd *Reader, _ rune) (Expr, error) {
 		return nil, err
 	}
 
-	return Vector(forms), nil
+	return Vector{Forms: forms}, nil
+}
+
+func readHashMap(rd *Reader, _ rune) (Expr, error) {
+	start := rd.position()
+
+	forms, err := readContainer(rd, '{', '}', "map")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(forms)%2 != 0 {
+		return nil, start.readerError(errors.New("map literal must contain an even number of forms"))
+	}
+
+	hm := HashMap{Position: start}
+	seen := map[string]bool{}
+	for i := 0; i < len(forms); i += 2 {
+		key := fmt.Sprintf("%T:%v", forms[i], forms[i])
+		if seen[key] {
+			return nil, start.readerError(fmt.Errorf("duplicate key '%v' in map literal", forms[i]))
+		}
+		seen[key] = true
+
+		hm.Keys = append(hm.Keys, forms[i])
+		hm.Values = append(hm.Values, forms[i+1])
+	}
+
+	return hm, nil
 }
 
 func readComment(rd *Reader, _ rune) (Expr, error) {
@@ -396,12 +470,73 @@ func quoteFormReader(expandFunc string) ReaderMacro {
 		}
 
 		return List{
-			Symbol(expandFunc),
-			expr,
+			Forms: []Expr{Symbol{Value: expandFunc}, expr},
 		}, nil
 	}
 }
 
+// withPosition attaches the start and end positions to the forms that can
+// carry positional information. Forms which already have position set by
+// the reader macro are returned as is.
+func withPosition(form Expr, start, end Position) Expr {
+	start.EndLine, start.EndColumn = end.Line, end.Column
+
+	switch f := form.(type) {
+	case Symbol:
+		if f.IsZero() {
+			f.Position = start
+		}
+		return f
+
+	case List:
+		if f.IsZero() {
+			f.Position = start
+		}
+		return f
+
+	case Vector:
+		if f.IsZero() {
+			f.Position = start
+		}
+		return f
+
+	case HashMap:
+		f.EndLine, f.EndColumn = end.Line, end.Column
+		if f.IsZero() {
+			f.Position = start
+		}
+		return f
+
+	case Set:
+		f.EndLine, f.EndColumn = end.Line, end.Column
+		if f.IsZero() {
+			f.Position = start
+		}
+		return f
+	}
+
+	return form
+}
+
+// readUnquote reads '~form' as (unquote form) and '~@form' as
+// (unquote-splicing form).
+func readUnquote(rd *Reader, init rune) (Expr, error) {
+	r, err := rd.NextRune()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("EOF while reading quote form")
+		}
+		return nil, err
+	}
+
+	if r == '@' {
+		return quoteFormReader("unquote-splicing")(rd, r)
+	}
+
+	rd.Unread(r)
+	return quoteFormReader("unquote")(rd, init)
+}
+
 func unmatchedDelimiter(_ *Reader, initRune rune) (Expr, error) {
 	return nil, fmt.Errorf("unmatched delimiter '%c'", initRune)
 }
@@ -548,11 +683,15 @@ func defaultReadTable() map[rune]ReaderMacro {
 		':':  readKeyword,
 		'\\': readCharacter,
 		'\'': quoteFormReader("quote"),
-		'~':  quoteFormReader("unquote"),
+		'`':  quoteFormReader("syntax-quote"),
+		'~':  readUnquote,
 		'(':  readList,
 		')':  unmatchedDelimiter,
 		'[':  readVector,
 		']':  unmatchedDelimiter,
+		'{':  readHashMap,
+		'}':  unmatchedDelimiter,
+		'#':  readDispatch,
 	}
 }
 
@@ -564,6 +703,15 @@ type ReaderError struct {
 	Column int
 }
 
+func (pos Position) readerError(cause error) ReaderError {
+	return ReaderError{
+		Cause:  cause,
+		File:   pos.File,
+		Line:   pos.Line,
+		Column: pos.Column,
+	}
+}
+
 func (err ReaderError) Error() string {
 	if e, ok := err.Cause.(ReaderError); ok {
 		return e.Error()
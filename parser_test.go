@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -51,14 +52,14 @@ func TestReader_SetMacro(t *testing.T) {
 		rd.SetMacro('~', nil) // remove unquote operator
 
 		var want parens.Expr
-		want = parens.Symbol("~hello")
+		want = parens.Symbol{Value: "~hello"}
 
 		got, err := rd.One()
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
 
-		if !reflect.DeepEqual(got, want) {
+		if !reflect.DeepEqual(withoutPosition(got), want) {
 			t.Errorf("got = %v, want = %v", got, want)
 		}
 	})
@@ -93,7 +94,7 @@ func TestReader_SetMacro(t *testing.T) {
 			t.Errorf("unexpected error: %v", err)
 		}
 
-		if !reflect.DeepEqual(got, want) {
+		if !reflect.DeepEqual(withoutPosition(got), want) {
 			t.Errorf("got = %v, want = %v", got, want)
 		}
 	})
@@ -118,10 +119,21 @@ func TestReader_All(t *testing.T) {
 				parens.Int64(10),
 				parens.Character('a'),
 				parens.Keyword(":hello"),
-				parens.List{
-					parens.Symbol("quote"),
-					parens.Symbol("hello"),
-				},
+				parens.List{Forms: []parens.Expr{
+					parens.Symbol{Value: "quote"},
+					parens.Symbol{Value: "hello"},
+				}},
+			},
+		},
+		{
+			name: "Nil",
+			src:  `nil [nil nil?]`,
+			want: parens.Module{
+				parens.Nil{},
+				parens.Vector{Forms: []parens.Expr{
+					parens.Nil{},
+					parens.Symbol{Value: "nil?"},
+				}},
 			},
 		},
 		{
@@ -172,6 +184,9 @@ func TestReader_All(t *testing.T) {
 				t.Errorf("All() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if got != nil {
+				got = withoutPosition(got).(parens.Module)
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("All() got = %v, want %v", got, tt.want)
 			}
@@ -197,16 +212,39 @@ func TestReader_One(t *testing.T) {
 			src:     "( 1",
 			wantErr: true,
 		},
+		{
+			name: "SyntaxQuote",
+			src:  "`(x ~y ~@z)",
+			want: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "syntax-quote"},
+				parens.List{Forms: []parens.Expr{
+					parens.Symbol{Value: "x"},
+					parens.List{Forms: []parens.Expr{
+						parens.Symbol{Value: "unquote"},
+						parens.Symbol{Value: "y"},
+					}},
+					parens.List{Forms: []parens.Expr{
+						parens.Symbol{Value: "unquote-splicing"},
+						parens.Symbol{Value: "z"},
+					}},
+				}},
+			}},
+		},
+		{
+			name:    "UnquoteEOF",
+			src:     "~",
+			wantErr: true,
+		},
 		{
 			name: "UnQuote",
 			src:  "~(x 3)",
-			want: parens.List{
-				parens.Symbol("unquote"),
-				parens.List{
-					parens.Symbol("x"),
+			want: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "unquote"},
+				parens.List{Forms: []parens.Expr{
+					parens.Symbol{Value: "x"},
 					parens.Int64(3),
-				},
-			},
+				}},
+			}},
 		},
 	})
 }
@@ -492,17 +530,17 @@ func TestReader_One_Symbol(t *testing.T) {
 		{
 			name: "SimpleASCII",
 			src:  `hello`,
-			want: parens.Symbol("hello"),
+			want: parens.Symbol{Value: "hello"},
 		},
 		{
 			name: "Unicode",
 			src:  `find-∂`,
-			want: parens.Symbol("find-∂"),
+			want: parens.Symbol{Value: "find-∂"},
 		},
 		{
 			name: "SingleChar",
 			src:  `+`,
-			want: parens.Symbol("+"),
+			want: parens.Symbol{Value: "+"},
 		},
 	})
 }
@@ -512,32 +550,32 @@ func TestReader_One_List(t *testing.T) {
 		{
 			name: "EmptyList",
 			src:  `()`,
-			want: parens.List(nil),
+			want: parens.List{},
 		},
 		{
 			name: "ListWithOneEntry",
 			src:  `(help)`,
-			want: parens.List{
-				parens.Symbol("help"),
-			},
+			want: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "help"},
+			}},
 		},
 		{
 			name: "ListWithMultipleEntry",
 			src:  `(+ 0xF 3.1413)`,
-			want: parens.List{
-				parens.Symbol("+"),
+			want: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "+"},
 				parens.Int64(15),
 				parens.Float64(3.1413),
-			},
+			}},
 		},
 		{
 			name: "ListWithCommaSeparator",
 			src:  `(+,0xF,3.1413)`,
-			want: parens.List{
-				parens.Symbol("+"),
+			want: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "+"},
 				parens.Int64(15),
 				parens.Float64(3.1413),
-			},
+			}},
 		},
 		{
 			name: "MultiLine",
@@ -545,11 +583,11 @@ func TestReader_One_List(t *testing.T) {
                       0xF
                       3.1413
 					)`,
-			want: parens.List{
-				parens.Symbol("+"),
+			want: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "+"},
 				parens.Int64(15),
 				parens.Float64(3.1413),
-			},
+			}},
 		},
 		{
 			name: "MultiLineWithComments",
@@ -557,11 +595,11 @@ func TestReader_One_List(t *testing.T) {
                       0xF    ; hex representation of 15
                       3.1413 ; value of math constant pi
                   )`,
-			want: parens.List{
-				parens.Symbol("+"),
+			want: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "+"},
 				parens.Int64(15),
 				parens.Float64(3.1413),
-			},
+			}},
 		},
 		{
 			name:    "UnexpectedEOF",
@@ -576,32 +614,32 @@ func TestReader_One_Vector(t *testing.T) {
 		{
 			name: "Empty",
 			src:  `[]`,
-			want: parens.Vector(nil),
+			want: parens.Vector{},
 		},
 		{
 			name: "WithOneEntry",
 			src:  `[help]`,
-			want: parens.Vector([]parens.Expr{
-				parens.Symbol("help"),
-			}),
+			want: parens.Vector{Forms: []parens.Expr{
+				parens.Symbol{Value: "help"},
+			}},
 		},
 		{
 			name: "WithMultipleEntry",
 			src:  `[+ 0xF 3.1413]`,
-			want: parens.Vector([]parens.Expr{
-				parens.Symbol("+"),
+			want: parens.Vector{Forms: []parens.Expr{
+				parens.Symbol{Value: "+"},
 				parens.Int64(15),
 				parens.Float64(3.1413),
-			}),
+			}},
 		},
 		{
 			name: "WithCommaSeparator",
 			src:  `[+,0xF,3.1413]`,
-			want: parens.Vector([]parens.Expr{
-				parens.Symbol("+"),
+			want: parens.Vector{Forms: []parens.Expr{
+				parens.Symbol{Value: "+"},
 				parens.Int64(15),
 				parens.Float64(3.1413),
-			}),
+			}},
 		},
 		{
 			name: "MultiLine",
@@ -609,11 +647,11 @@ func TestReader_One_Vector(t *testing.T) {
                       0xF
                       3.1413
 					]`,
-			want: parens.Vector([]parens.Expr{
-				parens.Symbol("+"),
+			want: parens.Vector{Forms: []parens.Expr{
+				parens.Symbol{Value: "+"},
 				parens.Int64(15),
 				parens.Float64(3.1413),
-			}),
+			}},
 		},
 		{
 			name: "MultiLineWithComments",
@@ -621,11 +659,11 @@ func TestReader_One_Vector(t *testing.T) {
                       0xF    ; hex representation of 15
                       3.1413 ; value of math constant pi
                   ]`,
-			want: parens.Vector([]parens.Expr{
-				parens.Symbol("+"),
+			want: parens.Vector{Forms: []parens.Expr{
+				parens.Symbol{Value: "+"},
 				parens.Int64(15),
 				parens.Float64(3.1413),
-			}),
+			}},
 		},
 		{
 			name:    "UnexpectedEOF",
@@ -635,6 +673,216 @@ func TestReader_One_Vector(t *testing.T) {
 	})
 }
 
+func TestReader_One_HashMap(t *testing.T) {
+	executeAllReaderTests(t, []readerTestCase{
+		{
+			name: "Empty",
+			src:  `{}`,
+			want: parens.HashMap{},
+		},
+		{
+			name: "WithEntries",
+			src:  `{:a 1, "b" [x]}`,
+			want: parens.HashMap{
+				Keys: []parens.Expr{
+					parens.Keyword(":a"),
+					parens.String("b"),
+				},
+				Values: []parens.Expr{
+					parens.Int64(1),
+					parens.Vector{Forms: []parens.Expr{
+						parens.Symbol{Value: "x"},
+					}},
+				},
+			},
+		},
+		{
+			name:    "OddNumberOfForms",
+			src:     `{:a 1 :b}`,
+			wantErr: true,
+		},
+		{
+			name:    "DuplicateKey",
+			src:     `{:a 1 :a 2}`,
+			wantErr: true,
+		},
+		{
+			name:    "UnexpectedEOF",
+			src:     `{:a 1`,
+			wantErr: true,
+		},
+		{
+			name:    "UnmatchedDelimiter",
+			src:     `}`,
+			wantErr: true,
+		},
+	})
+
+	t.Run("ErrorPosition", func(t *testing.T) {
+		_, err := parens.New(strings.NewReader("\n  {:a 1 :a 2}")).One()
+		rdErr, ok := err.(parens.ReaderError)
+		if !ok {
+			t.Fatalf("expected ReaderError, got %#v", err)
+		}
+
+		if cause, ok := rdErr.Cause.(parens.ReaderError); !ok || cause.Line != 2 || cause.Column != 3 {
+			t.Errorf("expected error at line 2 column 3, got %#v", rdErr.Cause)
+		}
+	})
+}
+
+func TestReader_One_Dispatch(t *testing.T) {
+	executeAllReaderTests(t, []readerTestCase{
+		{
+			name: "Set",
+			src:  `#{1 :a "b"}`,
+			want: parens.Set{Forms: []parens.Expr{
+				parens.Int64(1),
+				parens.Keyword(":a"),
+				parens.String("b"),
+			}},
+		},
+		{
+			name:    "SetWithDuplicates",
+			src:     `#{1 :a 1}`,
+			wantErr: true,
+		},
+		{
+			name: "AnonFn",
+			src:  `#(+ % %2)`,
+			want: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "lambda"},
+				parens.Vector{Forms: []parens.Expr{
+					parens.Symbol{Value: "%1"},
+					parens.Symbol{Value: "%2"},
+				}},
+				parens.List{Forms: []parens.Expr{
+					parens.Symbol{Value: "+"},
+					parens.Symbol{Value: "%1"},
+					parens.Symbol{Value: "%2"},
+				}},
+			}},
+		},
+		{
+			name: "AnonFnWithoutArgs",
+			src:  `#(hello)`,
+			want: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "lambda"},
+				parens.Vector{},
+				parens.List{Forms: []parens.Expr{
+					parens.Symbol{Value: "hello"},
+				}},
+			}},
+		},
+		{
+			name:    "NestedAnonFn",
+			src:     `#(map #(+ % 1) %)`,
+			wantErr: true,
+		},
+		{
+			name: "Discard",
+			src:  `#_ (hello world) :next`,
+			want: parens.Keyword(":next"),
+		},
+		{
+			name: "DiscardInsideList",
+			src:  `(hello #_world)`,
+			want: parens.List{Forms: []parens.Expr{
+				parens.Symbol{Value: "hello"},
+			}},
+		},
+		{
+			name:    "DiscardEOF",
+			src:     `#_`,
+			wantErr: true,
+		},
+		{
+			name: "Regex",
+			src:  `#"\d+\"x"`,
+			want: parens.Regex{Value: regexp.MustCompile(`\d+"x`)},
+		},
+		{
+			name:    "InvalidRegex",
+			src:     `#"(hello"`,
+			wantErr: true,
+		},
+		{
+			name:    "UnknownDispatch",
+			src:     `#hello`,
+			wantErr: true,
+		},
+		{
+			name:    "DispatchEOF",
+			src:     `#`,
+			wantErr: true,
+		},
+		{
+			name: "SymbolWithDispatchRune",
+			src:  `hello#`,
+			want: parens.Symbol{Value: "hello#"},
+		},
+	})
+}
+
+func TestReader_SetDispatchMacro(t *testing.T) {
+	t.Run("CustomMacro", func(t *testing.T) {
+		rd := parens.New(strings.NewReader("#!hello"))
+		rd.SetDispatchMacro('!', func(rd *parens.Reader, _ rune) (parens.Expr, error) {
+			form, err := rd.One()
+			if err != nil {
+				return nil, err
+			}
+
+			return parens.String(form.(parens.Symbol).Value), nil
+		})
+
+		got, err := rd.One()
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if want := parens.String("hello"); !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("UnsetDefaultMacro", func(t *testing.T) {
+		rd := parens.New(strings.NewReader("#_hello"))
+		rd.SetDispatchMacro('_', nil)
+
+		if _, err := rd.One(); err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+}
+
+func TestReader_One_Position(t *testing.T) {
+	got, err := parens.New(strings.NewReader("(foo\n  [a b])")).One()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lst, ok := got.(parens.List)
+	if !ok {
+		t.Fatalf("expected List, got %T", got)
+	}
+
+	want := parens.Position{File: "<string>", Line: 1, Column: 1, EndLine: 2, EndColumn: 8}
+	if lst.Position != want {
+		t.Errorf("list position = %#v, want %#v", lst.Position, want)
+	}
+
+	want = parens.Position{File: "<string>", Line: 1, Column: 2, EndLine: 1, EndColumn: 4}
+	if pos := lst.Forms[0].(parens.Symbol).Position; pos != want {
+		t.Errorf("symbol position = %#v, want %#v", pos, want)
+	}
+
+	want = parens.Position{File: "<string>", Line: 2, Column: 3, EndLine: 2, EndColumn: 7}
+	if pos := lst.Forms[1].(parens.Vector).Position; pos != want {
+		t.Errorf("vector position = %#v, want %#v", pos, want)
+	}
+}
+
 type readerTestCase struct {
 	name    string
 	src     string
@@ -652,9 +900,57 @@ func executeAllReaderTests(t *testing.T, tests []readerTestCase) {
 				t.Errorf("One() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
+			if !reflect.DeepEqual(withoutPosition(got), tt.want) {
 				t.Errorf("One() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+// withoutPosition clears the positional information from the form and its
+// sub-forms so that reader results can be compared with expected forms.
+func withoutPosition(form parens.Expr) parens.Expr {
+	switch f := form.(type) {
+	case parens.Symbol:
+		f.Position = parens.Position{}
+		return f
+
+	case parens.List:
+		f.Position = parens.Position{}
+		f.Forms = formsWithoutPosition(f.Forms)
+		return f
+
+	case parens.Vector:
+		f.Position = parens.Position{}
+		f.Forms = formsWithoutPosition(f.Forms)
+		return f
+
+	case parens.HashMap:
+		f.Position = parens.Position{}
+		f.Keys = formsWithoutPosition(f.Keys)
+		f.Values = formsWithoutPosition(f.Values)
+		return f
+
+	case parens.Set:
+		f.Position = parens.Position{}
+		f.Forms = formsWithoutPosition(f.Forms)
+		return f
+
+	case parens.Module:
+		return parens.Module(formsWithoutPosition(f))
+	}
+
+	return form
+}
+
+func formsWithoutPosition(forms []parens.Expr) []parens.Expr {
+	if forms == nil {
+		return nil
+	}
+
+	res := make([]parens.Expr, len(forms))
+	for i, form := range forms {
+		res[i] = withoutPosition(form)
+	}
+	return res
+}
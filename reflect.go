@@ -1,9 +1,14 @@
 package parens
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
 )
 
 var (
@@ -24,70 +29,95 @@ var (
 	ErrInvalidNumberOfArgs = errors.New("invalid number of arguments")
 )
 
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	keywordType = reflect.TypeOf(Keyword(""))
+)
+
 // reflectCall will execute a callable with given args. If the value bound
-// to the name is not a callable, ErrNotCallable will be returned.
-func reflectCall(callable interface{}, args ...interface{}) (interface{}, error) {
+// to the name is not a callable, ErrNotCallable will be returned. If the
+// first parameter of the callable is context.Context, ctx is passed in.
+// If the last return value of the callable is a non-nil error, it is
+// returned wrapped with the function name. Panics raised by the callable
+// are recovered and returned as errors.
+func reflectCall(ctx context.Context, callable interface{}, args ...interface{}) (_ interface{}, err error) {
+	defer recoverErr(&err)
+
 	rVal := reflect.ValueOf(callable)
 	if rVal.Kind() != reflect.Func {
 		return nil, fmt.Errorf("value of kind '%s' is not callable", rVal.Kind())
 	}
 	rType := rVal.Type()
 
-	argVals, err := makeArgs(rType, args...)
+	gc := &goCall{}
+	defer gc.finish()
+
+	argVals, err := makeArgs(ctx, gc, rType, args...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", funcName(rVal), err)
 	}
 
 	retVals := rVal.Call(argVals)
 
-	if rType.NumOut() == 0 {
+	if numOut := len(retVals); numOut > 0 && rType.Out(numOut-1) == errorType {
+		if errVal := retVals[numOut-1]; !errVal.IsNil() {
+			return nil, fmt.Errorf("%s: %w", funcName(rVal), errVal.Interface().(error))
+		}
+		retVals = retVals[:numOut-1]
+	}
+
+	convs := convertersOf(ctx)
+	if len(retVals) == 0 {
 		return nil, nil
-	} else if rType.NumOut() == 1 {
-		return retVals[0].Interface(), nil
+	} else if len(retVals) == 1 {
+		return convs.fromGo(retVals[0].Interface())
 	}
 
 	wrappedRetVals := []interface{}{}
 	for _, retVal := range retVals {
-		wrappedRetVals = append(wrappedRetVals, retVal.Interface())
+		v, err := convs.fromGo(retVal.Interface())
+		if err != nil {
+			return nil, err
+		}
+		wrappedRetVals = append(wrappedRetVals, v)
 	}
 	return wrappedRetVals, nil
 }
 
-func makeArgs(rType reflect.Type, args ...interface{}) ([]reflect.Value, error) {
+// makeArgs converts the args to the parameter types of the function. If the
+// first parameter is context.Context, ctx is passed in for it. Callables
+// converted to Go funcs share ctx only while gc is in progress.
+func makeArgs(ctx context.Context, gc *goCall, rType reflect.Type, args ...interface{}) ([]reflect.Value, error) {
 	argVals := []reflect.Value{}
+	convCtx := callContext{Context: ctx, call: gc}
 
-	if rType.IsVariadic() {
-		nonVariadicLength := rType.NumIn() - 1
-		for i := 0; i < nonVariadicLength; i++ {
-			convertedArgVal, err := convertValueType(args[i], rType.In(i))
-			if err != nil {
-				return nil, err
-			}
-
-			argVals = append(argVals, convertedArgVal)
-		}
-
-		variadicType := rType.In(nonVariadicLength).Elem()
-		for i := nonVariadicLength; i < len(args); i++ {
-			convertedArgVal, err := convertValueType(args[i], variadicType)
-			if err != nil {
-				return nil, err
-			}
+	first := 0
+	if rType.NumIn() > 0 && rType.In(0) == contextType {
+		argVals = append(argVals, reflect.ValueOf(&ctx).Elem())
+		first = 1
+	}
 
-			argVals = append(argVals, convertedArgVal)
+	numIn := rType.NumIn() - first
+	if rType.IsVariadic() {
+		if len(args) < numIn-1 {
+			return nil, fmt.Errorf("call requires at-least %d arguments, got %d", numIn-1, len(args))
 		}
-
-		return argVals, nil
+	} else if numIn != len(args) {
+		return nil, fmt.Errorf("call requires exactly %d arguments, got %d", numIn, len(args))
 	}
 
-	if rType.NumIn() != len(args) {
-		return nil, fmt.Errorf("call requires exactly %d arguments, got %d", rType.NumIn(), len(args))
-	}
+	for i, arg := range args {
+		paramIdx := first + i
+		var paramType reflect.Type
+		if rType.IsVariadic() && paramIdx >= rType.NumIn()-1 {
+			paramType = rType.In(rType.NumIn() - 1).Elem()
+		} else {
+			paramType = rType.In(paramIdx)
+		}
 
-	for i := 0; i < rType.NumIn(); i++ {
-		convertedArgVal, err := convertValueType(args[i], rType.In(i))
+		convertedArgVal, err := convertValueType(convCtx, arg, paramType)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
 		}
 
 		argVals = append(argVals, convertedArgVal)
@@ -96,13 +126,41 @@ func makeArgs(rType reflect.Type, args ...interface{}) ([]reflect.Value, error)
 	return argVals, nil
 }
 
-func convertValueType(v interface{}, expected reflect.Type) (reflect.Value, error) {
+// convertValueType converts v to the expected type. Callables (functions
+// and Invokables) are wrapped into the expected func type (see wrapCallable
+// for the context used when the wrapped callable is invoked).
+func convertValueType(ctx context.Context, v interface{}, expected reflect.Type) (reflect.Value, error) {
+	if conv := convertersOf(ctx).lookup(expected); conv != nil && (v == nil || reflect.TypeOf(v) != expected) {
+		converted, err := conv(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v = converted
+	}
+
+	if v == nil {
+		if !isNilable(expected) {
+			return reflect.Value{}, fmt.Errorf("invalid argument type: expected=%s, actual=nil", expected)
+		}
+		return reflect.Zero(expected), nil
+	}
+
 	val := newValue(v)
 	if val.RVal.Type() == expected {
 		return val.RVal, nil
 	}
 
-	converted, err := val.To(expected.Kind())
+	if expected.Kind() == reflect.Func && isCallable(v) {
+		return wrapCallable(ctx, v, expected), nil
+	}
+
+	if val.RVal.Type().AssignableTo(expected) {
+		rv := reflect.New(expected).Elem()
+		rv.Set(val.RVal)
+		return rv, nil
+	}
+
+	converted, err := val.To(ctx, expected)
 	if err != nil {
 		if err == ErrConversionImpossible {
 			return reflect.Value{}, fmt.Errorf("invalid argument type: expected=%s, actual=%s", expected, val.RVal.Type())
@@ -110,7 +168,152 @@ func convertValueType(v interface{}, expected reflect.Type) (reflect.Value, erro
 		return reflect.Value{}, err
 	}
 
-	return reflect.ValueOf(converted), nil
+	return converted, nil
+}
+
+// wrapCallable wraps the callable into a Go function of type fnType. Args
+// of the Go function are passed to the callable as is and the result is
+// converted to the return types of fnType. If the last return type is
+// error, errors from the callable are returned, otherwise they are raised
+// as panics.
+//
+// While the Go function receiving the callable as an argument is running,
+// the callable is invoked using ctx and is subject to its cancellation and
+// limits. Afterwards (e.g., a stored handler) and for callables converted
+// outside of a call (e.g., set-field!), a detached context keeping the
+// values of ctx (converters etc.) but not the cancellation and limits is
+// used.
+func wrapCallable(ctx context.Context, callable interface{}, fnType reflect.Type) reflect.Value {
+	var gc *goCall
+	if cc, ok := ctx.(callContext); ok {
+		ctx, gc = cc.Context, cc.call
+	}
+
+	return reflect.MakeFunc(fnType, func(in []reflect.Value) []reflect.Value {
+		ctx := ctx
+		if !gc.inProgress() {
+			ctx = Detach(ctx)
+		}
+
+		args := make([]interface{}, 0, len(in))
+		for i, arg := range in {
+			if fnType.IsVariadic() && i == len(in)-1 {
+				for j := 0; j < arg.Len(); j++ {
+					args = append(args, arg.Index(j).Interface())
+				}
+				break
+			}
+			args = append(args, arg.Interface())
+		}
+
+		convs := convertersOf(ctx)
+		for i, arg := range args {
+			v, err := convs.fromGo(arg)
+			if err != nil {
+				panic(err)
+			}
+			args[i] = v
+		}
+
+		res, err := call(ctx, callable, args...)
+		return makeReturns(ctx, fnType, res, err)
+	})
+}
+
+// goCall tracks a call to a Go function made by reflectCall.
+type goCall struct {
+	finished int32
+}
+
+// callContext is the context used for converting the arguments of a
+// call to a Go function.
+type callContext struct {
+	context.Context
+	call *goCall
+}
+
+func (gc *goCall) finish() { atomic.StoreInt32(&gc.finished, 1) }
+
+func (gc *goCall) inProgress() bool {
+	return gc != nil && atomic.LoadInt32(&gc.finished) == 0
+}
+
+func makeReturns(ctx context.Context, fnType reflect.Type, res interface{}, err error) []reflect.Value {
+	numOut := fnType.NumOut()
+	returnsErr := numOut > 0 && fnType.Out(numOut-1) == errorType
+	if returnsErr {
+		numOut--
+	}
+
+	if err != nil && !returnsErr {
+		panic(err)
+	}
+
+	outs := make([]reflect.Value, 0, fnType.NumOut())
+	if err != nil {
+		for i := 0; i < numOut; i++ {
+			outs = append(outs, reflect.Zero(fnType.Out(i)))
+		}
+		return append(outs, reflect.ValueOf(&err).Elem())
+	}
+
+	vals := []interface{}{res}
+	if numOut == 0 {
+		vals = nil
+	} else if numOut > 1 {
+		multi, ok := res.([]interface{})
+		if vec, isVec := res.(*PersistentVector); isVec {
+			multi, ok = vec.ToSlice(), true
+		}
+
+		if !ok || len(multi) != numOut {
+			panic(fmt.Errorf("%w: expecting %d return values", ErrInvalidNumberOfArgs, numOut))
+		}
+		vals = multi
+	}
+
+	for i, v := range vals {
+		out, err := convertValueType(ctx, v, fnType.Out(i))
+		if err != nil {
+			panic(err)
+		}
+		outs = append(outs, out)
+	}
+
+	if returnsErr {
+		if resErr, ok := res.(error); ok && numOut == 0 {
+			return append(outs, reflect.ValueOf(&resErr).Elem())
+		}
+		outs = append(outs, reflect.Zero(errorType))
+	}
+
+	return outs
+}
+
+func isCallable(v interface{}) bool {
+	switch v.(type) {
+	case Invokable:
+		return true
+
+	case MacroFunc:
+		return false
+
+	default:
+		return reflect.TypeOf(v).Kind() == reflect.Func
+	}
+}
+
+func funcName(rVal reflect.Value) string {
+	if fn := runtime.FuncForPC(rVal.Pointer()); fn != nil {
+		return fn.Name()
+	}
+
+	return rVal.Type().String()
+}
+
+func isNilable(rType reflect.Type) bool {
+	return isKind(reflect.Zero(rType), reflect.Interface, reflect.Ptr, reflect.Func,
+		reflect.Map, reflect.Slice, reflect.Chan)
 }
 
 func newValue(v interface{}) reflectVal {
@@ -123,30 +326,125 @@ type reflectVal struct {
 	RVal reflect.Value
 }
 
-// To converts the value to requested kind if possible.
-func (val *reflectVal) To(kind reflect.Kind) (interface{}, error) {
-	switch kind {
-	case reflect.Int, reflect.Int64:
-		return val.ToInt64()
-	case reflect.Float64:
-		return val.ToFloat64()
+// To converts the value to the expected type if possible.
+func (val *reflectVal) To(ctx context.Context, expected reflect.Type) (reflect.Value, error) {
+	rv := reflect.New(expected).Elem()
+
+	switch expected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := val.ToInt64()
+		if err != nil {
+			return reflect.Value{}, err
+		} else if rv.OverflowInt(i) {
+			return reflect.Value{}, fmt.Errorf("value %d overflows %s", i, expected)
+		}
+		rv.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := val.ToUint64()
+		if err != nil {
+			return reflect.Value{}, err
+		} else if rv.OverflowUint(u) {
+			return reflect.Value{}, fmt.Errorf("value %d overflows %s", u, expected)
+		}
+		rv.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := val.ToFloat64()
+		if err != nil {
+			return reflect.Value{}, err
+		} else if rv.OverflowFloat(f) {
+			return reflect.Value{}, fmt.Errorf("value %f overflows %s", f, expected)
+		}
+		rv.SetFloat(f)
+
 	case reflect.String:
-		return val.ToString()
+		str, err := val.ToString()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		if expected == keywordType {
+			return reflect.ValueOf(Keyword(":" + strings.TrimPrefix(str, ":"))), nil
+		}
+		rv.SetString(str)
+
 	case reflect.Bool:
-		return val.ToBool()
-	case reflect.Interface:
-		return val.RVal.Interface(), nil
+		b, err := val.ToBool()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.SetBool(b)
+
+	case reflect.Slice, reflect.Array:
+		return val.toSeq(ctx, expected)
+
+	case reflect.Map:
+		return val.toMap(ctx, expected)
+
+	case reflect.Ptr:
+		if val.RVal.Kind() == reflect.Ptr {
+			return reflect.Value{}, ErrConversionImpossible
+		}
+
+		elem, err := convertValueType(ctx, val.RVal.Interface(), expected.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv = reflect.New(expected.Elem())
+		rv.Elem().Set(elem)
+
+	case reflect.Struct:
+		if val.RVal.Kind() != reflect.Ptr || val.RVal.Type().Elem() != expected || val.RVal.IsNil() {
+			return reflect.Value{}, ErrConversionImpossible
+		}
+		rv.Set(val.RVal.Elem())
+
 	default:
-		return nil, ErrConversionImpossible
+		return reflect.Value{}, ErrConversionImpossible
 	}
+
+	return rv, nil
 }
 
 // ToInt64 attempts converting the value to int64.
 func (val *reflectVal) ToInt64() (int64, error) {
 	if val.isInt() {
 		return val.RVal.Int(), nil
+	} else if val.isUint() {
+		u := val.RVal.Uint()
+		if u > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d overflows int64", u)
+		}
+		return int64(u), nil
 	} else if val.isFloat() {
-		return int64(val.RVal.Float()), nil
+		f := val.RVal.Float()
+		if f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("value %f overflows int64", f)
+		}
+		return int64(f), nil
+	}
+
+	return 0, ErrConversionImpossible
+}
+
+// ToUint64 attempts converting the value to uint64.
+func (val *reflectVal) ToUint64() (uint64, error) {
+	if val.isUint() {
+		return val.RVal.Uint(), nil
+	} else if val.isInt() || val.isFloat() {
+		if (val.isInt() && val.RVal.Int() < 0) || (val.isFloat() && val.RVal.Float() < 0) {
+			return 0, fmt.Errorf("negative value %v cannot be converted to unsigned", val.RVal.Interface())
+		}
+
+		if val.isFloat() {
+			f := val.RVal.Float()
+			if f >= math.MaxUint64 {
+				return 0, fmt.Errorf("value %f overflows uint64", f)
+			}
+			return uint64(f), nil
+		}
+		return uint64(val.RVal.Int()), nil
 	}
 
 	return 0, ErrConversionImpossible
@@ -158,6 +456,8 @@ func (val *reflectVal) ToFloat64() (float64, error) {
 		return val.RVal.Float(), nil
 	} else if val.isInt() {
 		return float64(val.RVal.Int()), nil
+	} else if val.isUint() {
+		return float64(val.RVal.Uint()), nil
 	}
 
 	return 0, ErrConversionImpossible
@@ -172,19 +472,121 @@ func (val *reflectVal) ToBool() (bool, error) {
 	return false, ErrConversionImpossible
 }
 
-// ToString attempts converting the value to bool.
+// ToString attempts converting the value to string. Keywords are converted
+// to their names without the leading ':'.
 func (val *reflectVal) ToString() (string, error) {
-	if isKind(val.RVal, reflect.String) {
+	if val.RVal.Type() == keywordType {
+		return strings.TrimPrefix(val.RVal.String(), ":"), nil
+	} else if isKind(val.RVal, reflect.String) {
 		return val.RVal.String(), nil
 	}
 
 	return "", ErrConversionImpossible
 }
 
+// toSeq converts slices, arrays, vectors and Realizers into a slice or
+// array of the expected type.
+func (val *reflectVal) toSeq(ctx context.Context, expected reflect.Type) (reflect.Value, error) {
+	if val.RVal.IsValid() && val.RVal.CanInterface() {
+		switch v := val.RVal.Interface().(type) {
+		case *PersistentVector:
+			items := newValue(v.ToSlice())
+			return items.toSeq(ctx, expected)
+
+		case Realizer:
+			realized, err := v.Realize(ctx)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			items := newValue(realized)
+			return items.toSeq(ctx, expected)
+		}
+	}
+
+	if !isKind(val.RVal, reflect.Slice, reflect.Array) {
+		return reflect.Value{}, ErrConversionImpossible
+	}
+
+	n := val.RVal.Len()
+	var rv reflect.Value
+	if expected.Kind() == reflect.Array {
+		if n != expected.Len() {
+			return reflect.Value{}, fmt.Errorf("expecting %d items for %s, got %d", expected.Len(), expected, n)
+		}
+		rv = reflect.New(expected).Elem()
+	} else {
+		rv = reflect.MakeSlice(expected, n, n)
+	}
+
+	for i := 0; i < n; i++ {
+		item, err := convertValueType(ctx, val.RVal.Index(i).Interface(), expected.Elem())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("item %d: %w", i, err)
+		}
+		rv.Index(i).Set(item)
+	}
+
+	return rv, nil
+}
+
+// toMap converts Go maps and persistent maps and sets into a map of the
+// expected type. Items of sets are used as keys with zero values.
+func (val *reflectVal) toMap(ctx context.Context, expected reflect.Type) (reflect.Value, error) {
+	if val.RVal.IsValid() && val.RVal.CanInterface() {
+		switch v := val.RVal.Interface().(type) {
+		case *PersistentMap:
+			m := newValue(v.ToMap())
+			return m.toMap(ctx, expected)
+
+		case *PersistentSet:
+			m := reflect.MakeMapWithSize(expected, v.Len())
+			var err error
+			v.Range(func(item interface{}) bool {
+				var key reflect.Value
+				if key, err = convertValueType(ctx, item, expected.Key()); err != nil {
+					err = fmt.Errorf("item %v: %w", item, err)
+					return false
+				}
+
+				m.SetMapIndex(key, reflect.Zero(expected.Elem()))
+				return true
+			})
+			return m, err
+		}
+	}
+
+	if !isKind(val.RVal, reflect.Map) {
+		return reflect.Value{}, ErrConversionImpossible
+	}
+
+	rv := reflect.MakeMapWithSize(expected, val.RVal.Len())
+	iter := val.RVal.MapRange()
+	for iter.Next() {
+		key, err := convertValueType(ctx, iter.Key().Interface(), expected.Key())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("key %v: %w", iter.Key(), err)
+		}
+
+		v, err := convertValueType(ctx, iter.Value().Interface(), expected.Elem())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("value of key %v: %w", iter.Key(), err)
+		}
+
+		rv.SetMapIndex(key, v)
+	}
+
+	return rv, nil
+}
+
 func (val *reflectVal) isInt() bool {
 	return isKind(val.RVal, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64)
 }
 
+func (val *reflectVal) isUint() bool {
+	return isKind(val.RVal, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr)
+}
+
 func (val *reflectVal) isFloat() bool {
 	return isKind(val.RVal, reflect.Float32, reflect.Float64)
 }
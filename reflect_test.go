@@ -1,7 +1,11 @@
 package parens
 
 import (
+	"context"
+	"errors"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -29,7 +33,7 @@ func BenchmarkNonVariadicCall(suite *testing.B) {
 
 	suite.Run("Reflection", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			reflectCall(addFunc, 1, 2)
+			reflectCall(context.Background(), addFunc, 1, 2)
 		}
 	})
 
@@ -37,7 +41,7 @@ func BenchmarkNonVariadicCall(suite *testing.B) {
 		// addFunc expects float64 but forcefully passing int64 which
 		// triggers type-conversion
 		for i := 0; i < b.N; i++ {
-			reflectCall(addFunc, []interface{}{int64(1), int64(2)}...)
+			reflectCall(context.Background(), addFunc, []interface{}{int64(1), int64(2)}...)
 		}
 	})
 }
@@ -51,7 +55,7 @@ func BenchmarkVariadicCall(suite *testing.B) {
 
 	suite.Run("Reflection", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			reflectCall(sumFunc, 1, 2)
+			reflectCall(context.Background(), sumFunc, 1, 2)
 		}
 	})
 
@@ -59,7 +63,158 @@ func BenchmarkVariadicCall(suite *testing.B) {
 		// sumFunc expects float64 but forcefully passing int64 which
 		// triggers type-conversion
 		for i := 0; i < b.N; i++ {
-			reflectCall(sumFunc, []interface{}{int64(1), int64(2)}...)
+			reflectCall(context.Background(), sumFunc, []interface{}{int64(1), int64(2)}...)
 		}
 	})
 }
+
+func TestConvertValueType_Func(t *testing.T) {
+	t.Parallel()
+
+	double := func(v int64) int64 { return v * 2 }
+	rv, err := convertValueType(context.Background(), double, reflect.TypeOf(func(int) int { return 0 }))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := rv.Interface().(func(int) int)(21); got != 42 {
+		t.Errorf("expected 42, got %d", got)
+	}
+
+	failing := func() (interface{}, error) { return nil, ErrNotCallable }
+	rv, err = convertValueType(context.Background(), invokableFunc(failing), reflect.TypeOf(func() (string, error) { return "", nil }))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := rv.Interface().(func() (string, error))(); err != ErrNotCallable {
+		t.Errorf("expected ErrNotCallable, got %v", err)
+	}
+}
+
+type invokableFunc func() (interface{}, error)
+
+func (fn invokableFunc) Invoke(ctx context.Context, args ...interface{}) (interface{}, error) {
+	return fn()
+}
+
+func TestConvertValueType(t *testing.T) {
+	t.Parallel()
+
+	type color string
+
+	tests := []struct {
+		name     string
+		val      interface{}
+		expected reflect.Type
+		want     interface{}
+		wantErr  bool
+	}{
+		{name: "Int8", val: int64(-12), expected: reflect.TypeOf(int8(0)), want: int8(-12)},
+		{name: "Int8Overflow", val: int64(300), expected: reflect.TypeOf(int8(0)), wantErr: true},
+		{name: "Uint16", val: float64(42), expected: reflect.TypeOf(uint16(0)), want: uint16(42)},
+		{name: "UintNegative", val: int64(-1), expected: reflect.TypeOf(uint(0)), wantErr: true},
+		{name: "Float32", val: int64(3), expected: reflect.TypeOf(float32(0)), want: float32(3)},
+		{name: "Float32Overflow", val: float64(1e300), expected: reflect.TypeOf(float32(0)), wantErr: true},
+		{name: "KeywordToString", val: Keyword(":name"), expected: reflect.TypeOf(""), want: "name"},
+		{name: "StringToKeyword", val: "name", expected: keywordType, want: Keyword(":name")},
+		{name: "NamedString", val: "red", expected: reflect.TypeOf(color("")), want: color("red")},
+		{
+			name:     "VectorToSlice",
+			val:      []interface{}{int64(1), float64(2), int64(3)},
+			expected: reflect.TypeOf([]int{}),
+			want:     []int{1, 2, 3},
+		},
+		{
+			name:     "VectorToArray",
+			val:      []interface{}{"a", Keyword(":b")},
+			expected: reflect.TypeOf([2]string{}),
+			want:     [2]string{"a", "b"},
+		},
+		{
+			name:     "VectorItemMismatch",
+			val:      []interface{}{int64(1), "two"},
+			expected: reflect.TypeOf([]int{}),
+			wantErr:  true,
+		},
+		{
+			name:     "HashMapToMap",
+			val:      map[interface{}]interface{}{Keyword(":a"): int64(1)},
+			expected: reflect.TypeOf(map[string]uint8{}),
+			want:     map[string]uint8{"a": 1},
+		},
+		{name: "ValueToPointer", val: int64(5), expected: reflect.TypeOf(new(int)), want: func() *int { i := 5; return &i }()},
+		{name: "PointerToStruct", val: &struct{ A int }{A: 1}, expected: reflect.TypeOf(struct{ A int }{}), want: struct{ A int }{A: 1}},
+		{name: "NilSlice", val: nil, expected: reflect.TypeOf([]int{}), want: []int(nil)},
+		{name: "NilInt", val: nil, expected: reflect.TypeOf(0), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertValueType(context.Background(), tt.val, tt.expected)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertValueType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got.Interface(), tt.want) {
+				t.Errorf("convertValueType() = %#v, want %#v", got.Interface(), tt.want)
+			}
+		})
+	}
+}
+
+func TestReflectCall_ArgError(t *testing.T) {
+	t.Parallel()
+
+	_, err := reflectCall(context.Background(), add2, int64(1), "two")
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+
+	want := "github.com/spy16/parens.add2: argument 2: invalid argument type: expected=int, actual=string"
+	if err.Error() != want {
+		t.Errorf("expected error '%s', got '%s'", want, err)
+	}
+}
+
+func TestReflectCall_ErrorResult(t *testing.T) {
+	t.Parallel()
+
+	atoi := func(s string) (int, error) { return strconv.Atoi(s) }
+
+	got, err := reflectCall(context.Background(), atoi, "42")
+	if err != nil || got != 42 {
+		t.Errorf("expected (42, nil), got (%v, %v)", got, err)
+	}
+
+	_, err = reflectCall(context.Background(), strconv.Atoi, "forty-two")
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("expected error wrapping strconv.ErrSyntax, got %v", err)
+	} else if !strings.HasPrefix(err.Error(), "strconv.Atoi: ") {
+		t.Errorf("expected error to be prefixed with function name, got '%s'", err)
+	}
+
+	onlyErr := func(fail bool) error {
+		if fail {
+			return errors.New("failed")
+		}
+		return nil
+	}
+
+	got, err = reflectCall(context.Background(), onlyErr, false)
+	if err != nil || got != nil {
+		t.Errorf("expected (nil, nil), got (%v, %v)", got, err)
+	}
+
+	_, err = reflectCall(context.Background(), onlyErr, true)
+	if err == nil || !strings.HasSuffix(err.Error(), ": failed") {
+		t.Errorf("expected error 'failed', got %v", err)
+	}
+
+	pair := func() (int, string, error) { return 1, "one", nil }
+	got, err = reflectCall(context.Background(), pair)
+	if err != nil || !reflect.DeepEqual(got, []interface{}{1, "one"}) {
+		t.Errorf("expected ([1 one], nil), got (%v, %v)", got, err)
+	}
+}
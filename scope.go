@@ -1,10 +1,66 @@
 package parens
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 )
 
+// MutableScope is implemented by scopes that allow updating and removing
+// existing bindings. Scopes created using NewScope, NewConcurrentScope and
+// WithContext implement MutableScope.
+type MutableScope interface {
+	Scope
+
+	// Set updates the value bound to name in the nearest scope (i.e., this
+	// scope or one of its parents) that binds it. Metadata of the binding
+	// is retained except Arglists which describes the previous value. Error
+	// is returned if the name is not bound.
+	Set(name string, v interface{}) error
+
+	// Unbind removes the binding for name from this scope. Bindings in the
+	// parent scopes are not affected.
+	Unbind(name string) error
+}
+
+// IntrospectableScope is implemented by scopes that can enumerate their
+// bindings and associate metadata with them. Scopes created using NewScope,
+// NewConcurrentScope and WithContext implement IntrospectableScope.
+type IntrospectableScope interface {
+	Scope
+
+	// Names returns the sorted list of names bound in this scope and its
+	// parents.
+	Names() []string
+
+	// Meta returns the metadata of the binding for name visible from this
+	// scope. ok is false if the name is not bound.
+	Meta(name string) (meta Meta, ok bool)
+
+	// BindMeta is same as Bind but associates the metadata with the binding.
+	BindMeta(name string, v interface{}, meta Meta) error
+}
+
+// Meta represents the metadata of a binding.
+type Meta struct {
+	// Doc is the documentation of the binding.
+	Doc string
+
+	// Arglists contains the parameter vectors accepted by the function
+	// bound (e.g., "[x]", "[x y & more]").
+	Arglists []string
+
+	// Pos is the position of the form that created the binding. It is
+	// zero for bindings created from Go.
+	Pos Position
+
+	// Deprecated is a non-empty message (e.g., suggesting an alternative)
+	// if the binding is deprecated.
+	Deprecated string
+}
+
 // NewScope initializes a new scope with given parent scope. parent
 // can be nil.
 func NewScope(parent Scope) Scope {
@@ -14,14 +70,27 @@ func NewScope(parent Scope) Scope {
 	}
 }
 
+// NewConcurrentScope is same as NewScope but the returned scope is safe for
+// use by multiple goroutines. Use it for a global scope shared by scripts
+// that are executed concurrently. Sub-scopes created during an evaluation
+// are local to it and need not be concurrent.
+func NewConcurrentScope(parent Scope) Scope {
+	return &concurrentScope{
+		defaultScope: defaultScope{
+			parent: parent,
+			vals:   map[string]scopeEntry{},
+		},
+	}
+}
+
 type defaultScope struct {
 	parent Scope
 	vals   map[string]scopeEntry
 }
 
 type scopeEntry struct {
-	val reflectVal
-	doc string
+	val  reflectVal
+	meta Meta
 }
 
 func (sc *defaultScope) Root() Scope {
@@ -33,27 +102,91 @@ func (sc *defaultScope) Root() Scope {
 }
 
 func (sc *defaultScope) Bind(name string, v interface{}, doc ...string) error {
-	val := newValue(v)
+	return sc.BindMeta(name, v, Meta{
+		Doc: strings.TrimSpace(strings.Join(doc, "\n")),
+	})
+}
+
+func (sc *defaultScope) BindMeta(name string, v interface{}, meta Meta) error {
 	sc.vals[name] = scopeEntry{
-		val: val,
-		doc: strings.TrimSpace(strings.Join(doc, "\n")),
+		val:  newValue(v),
+		meta: meta,
 	}
 
 	return nil
 }
 
+func (sc *defaultScope) Set(name string, v interface{}) error {
+	if entry, found := sc.vals[name]; found {
+		entry.val = newValue(v)
+		entry.meta.Arglists = nil
+		sc.vals[name] = entry
+		return nil
+	}
+
+	if sc.parent == nil {
+		return fmt.Errorf("name '%s' not found", name)
+	}
+
+	ms, ok := sc.parent.(MutableScope)
+	if !ok {
+		return fmt.Errorf("cannot set '%s': scope of type '%T' is not mutable", name, sc.parent)
+	}
+	return ms.Set(name, v)
+}
+
+func (sc *defaultScope) Unbind(name string) error {
+	delete(sc.vals, name)
+	return nil
+}
+
 func (sc *defaultScope) Doc(name string) string {
+	meta, _ := sc.Meta(name)
+	return meta.Doc
+}
+
+func (sc *defaultScope) Meta(name string) (Meta, bool) {
 	if entry := sc.entry(name); entry != nil {
-		return entry.doc
+		return entry.meta, true
 	}
 
-	if sc.parent != nil {
-		if swd, ok := sc.parent.(scopeWithDoc); ok {
-			return swd.Doc(name)
+	switch parent := sc.parent.(type) {
+	case IntrospectableScope:
+		return parent.Meta(name)
+
+	case scopeWithDoc:
+		if _, err := sc.parent.Get(name); err == nil {
+			return Meta{Doc: parent.Doc(name)}, true
 		}
 	}
 
-	return ""
+	return Meta{}, false
+}
+
+func (sc *defaultScope) Names() []string {
+	names := make([]string, 0, len(sc.vals))
+	for name := range sc.vals {
+		names = append(names, name)
+	}
+
+	if is, ok := sc.parent.(IntrospectableScope); ok {
+		for _, name := range is.Names() {
+			if _, found := sc.vals[name]; !found {
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (sc *defaultScope) Context() context.Context {
+	if sc.parent == nil {
+		return context.Background()
+	}
+
+	return ContextOf(sc.parent)
 }
 
 func (sc *defaultScope) Get(name string) (interface{}, error) {
@@ -65,6 +198,10 @@ func (sc *defaultScope) Get(name string) (interface{}, error) {
 		return nil, fmt.Errorf("name '%s' not found", name)
 	}
 
+	if !entry.val.RVal.IsValid() {
+		return nil, nil
+	}
+
 	return entry.val.RVal.Interface(), nil
 }
 
@@ -85,6 +222,74 @@ func (sc *defaultScope) entry(name string) *scopeEntry {
 	return nil
 }
 
+// concurrentScope guards the bindings of defaultScope using a RWMutex.
+type concurrentScope struct {
+	mu sync.RWMutex
+	defaultScope
+}
+
+func (sc *concurrentScope) Root() Scope {
+	if sc.parent == nil {
+		return sc
+	}
+
+	return sc.parent.Root()
+}
+
+func (sc *concurrentScope) Bind(name string, v interface{}, doc ...string) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.defaultScope.Bind(name, v, doc...)
+}
+
+func (sc *concurrentScope) BindMeta(name string, v interface{}, meta Meta) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.defaultScope.BindMeta(name, v, meta)
+}
+
+func (sc *concurrentScope) Set(name string, v interface{}) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.defaultScope.Set(name, v)
+}
+
+func (sc *concurrentScope) Unbind(name string) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.defaultScope.Unbind(name)
+}
+
+func (sc *concurrentScope) Doc(name string) string {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.defaultScope.Doc(name)
+}
+
+func (sc *concurrentScope) Meta(name string) (Meta, bool) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.defaultScope.Meta(name)
+}
+
+func (sc *concurrentScope) Names() []string {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.defaultScope.Names()
+}
+
+func (sc *concurrentScope) Get(name string) (interface{}, error) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.defaultScope.Get(name)
+}
+
+func (sc *concurrentScope) String() string {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.defaultScope.String()
+}
+
 type scopeWithDoc interface {
 	Doc(name string) string
 }
@@ -1,8 +1,10 @@
 package parens
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,6 +25,15 @@ func TestScope_Bind(suite *testing.T) {
 		assert.Equal(t, "1.0.0", val)
 	})
 
+	suite.Run("NilBind", func(t *testing.T) {
+		scope := NewScope(nil)
+		scope.Bind("nothing", nil)
+
+		val, err := scope.Get("nothing")
+		assert.NoError(t, err)
+		assert.Nil(t, val)
+	})
+
 	suite.Run("FunctionBind", func(t *testing.T) {
 		scope := NewScope(nil)
 		scope.Bind("print", func(msg string) { fmt.Println(msg) })
@@ -82,3 +93,152 @@ func TestScope_Get(suite *testing.T) {
 		assert.Equal(t, &actualValue, val)
 	})
 }
+
+func TestConcurrentScope(t *testing.T) {
+	t.Parallel()
+
+	global := NewConcurrentScope(nil)
+	global.Bind("shared", "value", "a shared value")
+	assert.Equal(t, global, global.Root())
+	assert.Equal(t, global, NewScope(global).Root())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("name-%d", i)
+			for j := 0; j < 100; j++ {
+				assert.NoError(t, global.Bind(name, j))
+
+				val, err := NewScope(global).Get(name)
+				assert.NoError(t, err)
+				assert.IsType(t, 0, val)
+
+				val, err = global.Get("shared")
+				assert.NoError(t, err)
+				assert.Equal(t, "value", val)
+				assert.Equal(t, "a shared value", global.(scopeWithDoc).Doc("shared"))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 20; i++ {
+		val, err := global.Get(fmt.Sprintf("name-%d", i))
+		assert.NoError(t, err)
+		assert.Equal(t, 99, val)
+	}
+}
+
+func TestScope_Set(suite *testing.T) {
+	suite.Parallel()
+
+	suite.Run("NearestScope", func(t *testing.T) {
+		global := NewScope(nil)
+		global.(IntrospectableScope).BindMeta("counter", int64(1), Meta{
+			Doc:      "a counter",
+			Arglists: []string{"[]"},
+		})
+
+		local := NewScope(WithContext(context.Background(), NewScope(global)))
+		require.NoError(t, local.(MutableScope).Set("counter", int64(2)))
+
+		val, err := global.Get("counter")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), val)
+
+		meta, found := global.(IntrospectableScope).Meta("counter")
+		assert.True(t, found)
+		assert.Equal(t, "a counter", meta.Doc)
+		assert.Empty(t, meta.Arglists)
+	})
+
+	suite.Run("Shadowed", func(t *testing.T) {
+		global := NewScope(nil)
+		global.Bind("x", "global")
+
+		local := NewScope(global)
+		local.Bind("x", "local")
+		require.NoError(t, local.(MutableScope).Set("x", "updated"))
+
+		val, _ := global.Get("x")
+		assert.Equal(t, "global", val)
+		val, _ = local.Get("x")
+		assert.Equal(t, "updated", val)
+	})
+
+	suite.Run("Unbound", func(t *testing.T) {
+		scope := NewScope(NewConcurrentScope(nil))
+		err := scope.(MutableScope).Set("x", 1)
+		assert.EqualError(t, err, "name 'x' not found")
+	})
+}
+
+func TestScope_Unbind(t *testing.T) {
+	t.Parallel()
+
+	global := NewScope(nil)
+	global.Bind("x", "global")
+
+	local := WithContext(context.Background(), NewScope(global))
+	local.Bind("x", "local")
+
+	require.NoError(t, local.(MutableScope).Unbind("x"))
+	val, err := local.Get("x")
+	assert.NoError(t, err)
+	assert.Equal(t, "global", val)
+
+	require.NoError(t, local.(MutableScope).Unbind("x"))
+	val, _ = local.Get("x")
+	assert.Equal(t, "global", val, "unbind must not affect parent scopes")
+
+	require.NoError(t, global.(MutableScope).Unbind("x"))
+	_, err = local.Get("x")
+	assert.Error(t, err)
+}
+
+func TestScope_Names(t *testing.T) {
+	t.Parallel()
+
+	global := NewConcurrentScope(nil)
+	global.Bind("b", 1)
+	global.Bind("a", 2)
+
+	local := WithContext(context.Background(), NewScope(global))
+	local.Bind("c", 3)
+	local.Bind("a", 4)
+
+	assert.Equal(t, []string{"a", "b", "c"}, local.(IntrospectableScope).Names())
+	assert.Equal(t, []string{"a", "b"}, global.(IntrospectableScope).Names())
+}
+
+func TestScope_Meta(t *testing.T) {
+	t.Parallel()
+
+	want := Meta{
+		Doc:        "adds numbers",
+		Arglists:   []string{"[x y]"},
+		Pos:        Position{File: "math.lisp", Line: 1, Column: 2},
+		Deprecated: "use + instead",
+	}
+
+	global := NewScope(nil)
+	require.NoError(t, global.(IntrospectableScope).BindMeta("add", func(x, y int) int { return x + y }, want))
+	global.Bind("sub", nil, "subtracts", "numbers")
+
+	local := WithContext(context.Background(), NewScope(global))
+
+	meta, found := local.(IntrospectableScope).Meta("add")
+	assert.True(t, found)
+	assert.Equal(t, want, meta)
+	assert.Equal(t, "adds numbers", local.(scopeWithDoc).Doc("add"))
+
+	meta, found = local.(IntrospectableScope).Meta("sub")
+	assert.True(t, found)
+	assert.Equal(t, Meta{Doc: "subtracts\nnumbers"}, meta)
+
+	_, found = local.(IntrospectableScope).Meta("mul")
+	assert.False(t, found)
+}
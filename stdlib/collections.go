@@ -0,0 +1,330 @@
+package stdlib
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/spy16/parens"
+)
+
+var collections = []mapEntry{
+	entry("get", Get,
+		"Returns the value mapped to key in a map or the value at index in a vector.",
+		"Returns default (or nil) if the key is not present.",
+		"Usage: (get coll key [default])",
+	),
+	entry("assoc", Assoc,
+		"Returns a new map with the key-value pairs added to the given map",
+		"Usage: (assoc map key val & kvs)",
+	),
+	entry("dissoc", Dissoc,
+		"Returns a new map without the given keys",
+		"Usage: (dissoc map key & keys)",
+	),
+	entry("keys", Keys,
+		"Returns a vector of keys of the map in no particular order",
+		"Usage: (keys map)",
+	),
+	entry("vals", Vals,
+		"Returns a vector of values of the map in no particular order",
+		"Usage: (vals map)",
+	),
+	entry("contains?", Contains,
+		"Returns true if the key is present in a map or the index is valid for a vector",
+		"Usage: (contains? coll key)",
+	),
+}
+
+// Get returns the value mapped to key in a map, the value at index key in
+// a vector or slice or the item itself if it is in a set. If the key is not
+// present, default value (if given) or nil is returned.
+func Get(coll interface{}, key interface{}, def ...interface{}) interface{} {
+	if len(def) > 1 {
+		panic(fmt.Errorf("at-most 1 default value allowed, got %d", len(def)))
+	}
+
+	var defVal interface{}
+	if len(def) == 1 {
+		defVal = def[0]
+	}
+
+	switch c := coll.(type) {
+	case *parens.PersistentMap:
+		if v, found := c.Get(key); found {
+			return v
+		}
+		return defVal
+
+	case *parens.PersistentVector:
+		if idx, ok := vectorIndex(c, key); ok {
+			v, _ := c.Nth(idx)
+			return v
+		}
+		return defVal
+
+	case *parens.PersistentSet:
+		if c.Contains(key) {
+			return key
+		}
+		return defVal
+	}
+
+	rv := reflect.ValueOf(coll)
+	switch rv.Kind() {
+	case reflect.Map:
+		kv, ok := mapKey(rv, key)
+		if !ok {
+			return defVal
+		}
+
+		v := rv.MapIndex(kv)
+		if !v.IsValid() {
+			return defVal
+		}
+		return v.Interface()
+
+	case reflect.Slice, reflect.Array:
+		idx, ok := sliceIndex(rv, key)
+		if !ok {
+			return defVal
+		}
+		return rv.Index(idx).Interface()
+	}
+
+	return defVal
+}
+
+// Assoc returns a copy of the map with the given key-value pairs added. A nil
+// map is treated as an empty map. For vectors, keys must be indices and the
+// items at those indices are replaced.
+func Assoc(ctx context.Context, m interface{}, kvs ...interface{}) (interface{}, error) {
+	return allocated(ctx, assoc(m, kvs...))
+}
+
+func assoc(m interface{}, kvs ...interface{}) interface{} {
+	if len(kvs) == 0 || len(kvs)%2 != 0 {
+		panic(fmt.Errorf("even number of key-value arguments required, got %d", len(kvs)))
+	}
+
+	switch c := m.(type) {
+	case nil:
+		return assoc(&parens.PersistentMap{}, kvs...)
+
+	case *parens.PersistentMap:
+		tm := c.Transient()
+		for i := 0; i < len(kvs); i += 2 {
+			if err := tm.Assoc(kvs[i], kvs[i+1]); err != nil {
+				panic(err)
+			}
+		}
+		return tm.Persistent()
+
+	case *parens.PersistentVector:
+		tv := c.Transient()
+		for i := 0; i < len(kvs); i += 2 {
+			idx, ok := kvs[i].(int64)
+			if !ok {
+				panic(fmt.Errorf("vector index must be an integer, not '%s'", reflect.TypeOf(kvs[i])))
+			}
+
+			if err := tv.Assoc(int(idx), kvs[i+1]); err != nil {
+				panic(err)
+			}
+		}
+		return tv.Persistent()
+	}
+
+	res := copyMap(m)
+	for i := 0; i < len(kvs); i += 2 {
+		kv, ok := mapKey(res, kvs[i])
+		if !ok {
+			panic(fmt.Errorf("key of type '%s' cannot be used with '%s'", reflect.TypeOf(kvs[i]), res.Type()))
+		}
+
+		vv, ok := mapValue(res, kvs[i+1])
+		if !ok {
+			panic(fmt.Errorf("value of type '%s' cannot be used with '%s'", reflect.TypeOf(kvs[i+1]), res.Type()))
+		}
+
+		res.SetMapIndex(kv, vv)
+	}
+
+	return res.Interface()
+}
+
+// Dissoc returns a copy of the map without the given keys.
+func Dissoc(ctx context.Context, m interface{}, keys ...interface{}) (interface{}, error) {
+	return allocated(ctx, dissoc(m, keys...))
+}
+
+func dissoc(m interface{}, keys ...interface{}) interface{} {
+	if pm, ok := m.(*parens.PersistentMap); ok {
+		tm := pm.Transient()
+		for _, key := range keys {
+			tm.Dissoc(key)
+		}
+		return tm.Persistent()
+	}
+
+	res := copyMap(m)
+	for _, key := range keys {
+		if kv, ok := mapKey(res, key); ok {
+			res.SetMapIndex(kv, reflect.Value{})
+		}
+	}
+
+	return res.Interface()
+}
+
+// Keys returns all the keys of the map.
+func Keys(ctx context.Context, m interface{}) (*parens.PersistentVector, error) {
+	if pm, ok := m.(*parens.PersistentMap); ok {
+		return allocatedVector(ctx, parens.NewVector(pm.Keys()...))
+	}
+
+	rv := mustMap(m)
+
+	res := make([]interface{}, 0, rv.Len())
+	for _, key := range rv.MapKeys() {
+		res = append(res, key.Interface())
+	}
+	return allocatedVector(ctx, parens.NewVector(res...))
+}
+
+// Vals returns all the values of the map.
+func Vals(ctx context.Context, m interface{}) (*parens.PersistentVector, error) {
+	if pm, ok := m.(*parens.PersistentMap); ok {
+		return allocatedVector(ctx, parens.NewVector(pm.Vals()...))
+	}
+
+	rv := mustMap(m)
+
+	res := make([]interface{}, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		res = append(res, iter.Value().Interface())
+	}
+	return allocatedVector(ctx, parens.NewVector(res...))
+}
+
+// Contains returns true if the key is present in a map or a set or if the
+// key is a valid index for a vector or slice.
+func Contains(coll interface{}, key interface{}) bool {
+	switch c := coll.(type) {
+	case *parens.PersistentMap:
+		_, found := c.Get(key)
+		return found
+
+	case *parens.PersistentVector:
+		_, ok := vectorIndex(c, key)
+		return ok
+
+	case *parens.PersistentSet:
+		return c.Contains(key)
+	}
+
+	rv := reflect.ValueOf(coll)
+	switch rv.Kind() {
+	case reflect.Map:
+		kv, ok := mapKey(rv, key)
+		return ok && rv.MapIndex(kv).IsValid()
+
+	case reflect.Slice, reflect.Array:
+		_, ok := sliceIndex(rv, key)
+		return ok
+	}
+
+	return false
+}
+
+func mustMap(m interface{}) reflect.Value {
+	if m == nil {
+		return reflect.ValueOf(map[interface{}]interface{}{})
+	}
+
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Map {
+		panic(fmt.Errorf("argument must be a map, not '%s'", rv.Type()))
+	}
+	return rv
+}
+
+func copyMap(m interface{}) reflect.Value {
+	rv := mustMap(m)
+
+	res := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		res.SetMapIndex(iter.Key(), iter.Value())
+	}
+	return res
+}
+
+func mapKey(m reflect.Value, key interface{}) (reflect.Value, bool) {
+	return assignable(key, m.Type().Key())
+}
+
+func mapValue(m reflect.Value, val interface{}) (reflect.Value, bool) {
+	return assignable(val, m.Type().Elem())
+}
+
+// assignable returns v as a value of type t if v is assignable or can be
+// converted to t without changing its kind.
+func assignable(v interface{}, t reflect.Type) (reflect.Value, bool) {
+	if v == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), true
+		}
+		return reflect.Value{}, false
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Type().AssignableTo(t) {
+		return rv, true
+	}
+
+	if rv.Kind() == t.Kind() && rv.Type().ConvertibleTo(t) {
+		return rv.Convert(t), true
+	}
+
+	return reflect.Value{}, false
+}
+
+func sliceIndex(rv reflect.Value, key interface{}) (int, bool) {
+	var idx int
+	switch k := key.(type) {
+	case int64:
+		idx = int(k)
+	case int:
+		idx = k
+	default:
+		return 0, false
+	}
+
+	if idx < 0 || idx >= rv.Len() {
+		return 0, false
+	}
+	return idx, true
+}
+
+func vectorIndex(vec *parens.PersistentVector, key interface{}) (int, bool) {
+	return sliceIndex(reflect.ValueOf(make([]struct{}, vec.Len())), key)
+}
+
+// allocated returns v after accounting it as a newly created value against
+// the limits of ctx.
+func allocated(ctx context.Context, v interface{}) (interface{}, error) {
+	if err := parens.Allocate(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func allocatedVector(ctx context.Context, vec *parens.PersistentVector) (*parens.PersistentVector, error) {
+	if err := parens.Allocate(ctx, vec); err != nil {
+		return nil, err
+	}
+	return vec, nil
+}
@@ -0,0 +1,196 @@
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spy16/parens"
+)
+
+// If evaluates the test and evaluates then if the result is truthy or else
+// otherwise. Returns nil if the test fails and else is not provided.
+func If(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	if len(exprs) != 2 && len(exprs) != 3 {
+		return nil, fmt.Errorf("2 or 3 arguments required, got %d", len(exprs))
+	}
+
+	ok, err := evalTest(scope, exprs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return exprs[1].Eval(scope)
+	} else if len(exprs) == 3 {
+		return exprs[2].Eval(scope)
+	}
+
+	return nil, nil
+}
+
+// When evaluates the body if the test is truthy and returns the result of
+// last form. Returns nil otherwise.
+func When(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	return whenTest(true, scope, exprs)
+}
+
+// Unless evaluates the body if the test is not truthy and returns the result
+// of last form. Returns nil otherwise.
+func Unless(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	return whenTest(false, scope, exprs)
+}
+
+// And evaluates the forms one by one and returns the first value that is
+// not truthy. Returns the last value if all are truthy and true if there
+// are no forms.
+func And(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	var val interface{} = true
+	for _, expr := range exprs {
+		var err error
+		if val, err = expr.Eval(scope); err != nil {
+			return nil, err
+		} else if !parens.IsTruthy(val) {
+			return val, nil
+		}
+	}
+
+	return val, nil
+}
+
+// Or evaluates the forms one by one and returns the first truthy value.
+// Returns the last value if none are truthy and nil if there are no forms.
+func Or(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	var val interface{}
+	for _, expr := range exprs {
+		var err error
+		if val, err = expr.Eval(scope); err != nil {
+			return nil, err
+		} else if parens.IsTruthy(val) {
+			return val, nil
+		}
+	}
+
+	return val, nil
+}
+
+// Case evaluates the first argument and compares the result with the test
+// constants (not evaluated) in order. The result expression paired with the
+// first matching constant is evaluated. A list of constants matches if any
+// one of them matches. If none match, the trailing default expression is
+// evaluated if provided, otherwise an error is returned.
+func Case(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	if len(exprs) < 1 {
+		return nil, errors.New("at-least 1 argument required")
+	}
+
+	val, err := exprs[0].Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	clauses := exprs[1:]
+	for i := 0; i+1 < len(clauses); i += 2 {
+		matched, err := caseMatches(scope, clauses[i], val)
+		if err != nil {
+			return nil, err
+		} else if matched {
+			return clauses[i+1].Eval(scope)
+		}
+	}
+
+	if len(clauses)%2 == 1 {
+		return clauses[len(clauses)-1].Eval(scope)
+	}
+
+	return nil, fmt.Errorf("no matching clause for '%v'", val)
+}
+
+func caseMatches(scope parens.Scope, test parens.Expr, val interface{}) (bool, error) {
+	tests := []parens.Expr{test}
+	if lst, ok := test.(parens.List); ok {
+		tests = lst.Forms
+	}
+
+	for _, t := range tests {
+		constant, err := caseConstant(scope, t)
+		if err != nil {
+			return false, err
+		}
+
+		if caseEqual(constant, val) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// caseConstant returns the value of the test constant. Symbols are used as
+// is and other literals are evaluated to obtain the value.
+func caseConstant(scope parens.Scope, expr parens.Expr) (interface{}, error) {
+	switch expr.(type) {
+	case parens.Symbol:
+		return expr, nil
+
+	case parens.List, parens.Vector, parens.HashMap:
+		return nil, fmt.Errorf("invalid case constant '%s'", expr)
+
+	default:
+		return expr.Eval(scope)
+	}
+}
+
+// caseEqual compares the constant with the value. Numbers are compared by
+// value irrespective of their types and symbols are compared by name.
+func caseEqual(constant, val interface{}) bool {
+	switch c := constant.(type) {
+	case parens.Symbol:
+		sym, ok := val.(parens.Symbol)
+		return ok && sym.Value == c.Value
+
+	case int64, float64:
+		cf, _ := toFloat64(c)
+		vf, ok := toFloat64(val)
+		return ok && cf == vf
+	}
+
+	return Eq(constant, val)
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+
+	case float64:
+		return n, true
+	}
+
+	return 0, false
+}
+
+func whenTest(want bool, scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	if len(exprs) < 1 {
+		return nil, errors.New("at-least 1 argument required")
+	}
+
+	ok, err := evalTest(scope, exprs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if ok != want {
+		return nil, nil
+	}
+
+	return Do(scope, exprs[1:])
+}
+
+func evalTest(scope parens.Scope, test parens.Expr) (bool, error) {
+	val, err := test.Eval(scope)
+	if err != nil {
+		return false, err
+	}
+
+	return parens.IsTruthy(val), nil
+}
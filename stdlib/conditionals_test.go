@@ -0,0 +1,83 @@
+package stdlib_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spy16/parens"
+	"github.com/spy16/parens/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionals(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		want    interface{}
+		wantErr string
+	}{
+		{name: "IfThen", src: `(if (> 2 1) "yes" "no")`, want: "yes"},
+		{name: "IfElse", src: `(if nil "yes" "no")`, want: "no"},
+		{name: "IfNoElse", src: `(if false "yes")`, want: nil},
+		{name: "IfTruthyZero", src: `(if 0 "yes" "no")`, want: "yes"},
+		{name: "IfArgs", src: `(if true)`, wantErr: "2 or 3 arguments required, got 1"},
+		{name: "When", src: `(when true 1 2)`, want: int64(2)},
+		{name: "WhenFalse", src: `(when false (undefined))`, want: nil},
+		{name: "Unless", src: `(unless false 1 2)`, want: int64(2)},
+		{name: "UnlessTrue", src: `(unless true (undefined))`, want: nil},
+		{name: "AndEmpty", src: `(and)`, want: true},
+		{name: "AndAllTruthy", src: `(and 1 "a" :b)`, want: parens.Keyword(":b")},
+		{name: "AndShortCircuit", src: `(and 1 false (undefined))`, want: false},
+		{name: "OrEmpty", src: `(or)`, want: nil},
+		{name: "OrFirstTruthy", src: `(or false "a" (undefined))`, want: "a"},
+		{name: "OrNoneTruthy", src: `(or false false)`, want: false},
+		{name: "Case", src: `(case :b :a 1 :b 2)`, want: int64(2)},
+		{name: "CaseList", src: `(case "y" ("x" "y") "matched" "default")`, want: "matched"},
+		{name: "CaseSymbol", src: `(case 'foo foo "sym" "default")`, want: "sym"},
+		{name: "CaseDefault", src: `(case 10 1 "one" "default")`, want: "default"},
+		{name: "CaseNoMatch", src: `(case 10 1 "one")`, wantErr: "no matching clause for '10'"},
+		{name: "NotUsesTruthiness", src: `[(not nil) (not 0) (not false)]`, want: []interface{}{true, false, true}},
+		{name: "NilLiteral", src: `(if nil "yes" "no")`, want: "no"},
+		{name: "NilPointer", src: `(if (nil-ptr) "yes" "no")`, want: "no"},
+		{name: "NilPredicate", src: `[(nil? nil) (nil? (nil-ptr)) (nil? false) (nil? 0)]`, want: []interface{}{true, true, false, false}},
+		{name: "EmptyListIsNil", src: `[(nil? ()) (if () "truthy" "falsey")]`, want: []interface{}{true, "falsey"}},
+		{name: "NilInSyntaxQuote", src: "(defmacro m [] `(or nil ~nil :x)) (m)", want: parens.Keyword(":x")},
+		{
+			name: "LetWithConditionals",
+			src: `(let [a 1 b (+ a 1)]
+                    (if (and (> b a) (or false b)) (+ a b) 0))`,
+			want: float64(3),
+		},
+		{
+			name: "TailPositions",
+			src: `(defn count-down [n]
+                    (if (< n 1)
+                      (case n 0 "done" "negative")
+                      (when true (unless false (and true (or false (count-down (- n 1))))))))
+                  (count-down 100000)`,
+			want: "done",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+			scope.Bind("nil-ptr", func() *struct{} { return nil })
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasSuffix(err.Error(), tt.wantErr), "unexpected error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, parens.ToGo(got))
+		})
+	}
+}
@@ -1,14 +1,12 @@
 package stdlib
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"fmt"
-	"os"
 	"reflect"
 	"strings"
 
-	"github.com/k0kubun/pp"
 	"github.com/spy16/parens"
 )
 
@@ -20,8 +18,14 @@ var core = []mapEntry{
 	entry("false", false,
 		"Represents logical false",
 	),
-	entry("nil", false,
-		"Represents logical false. Same as false",
+	entry("nil", nil,
+		"Represents absence of a value. nil and false are the only values",
+		"that are not truthy",
+	),
+	entry("nil?", parens.IsNil,
+		"Returns true if the value is nil. Nil pointers, maps, slices etc.",
+		"returned from Go functions are also nil",
+		"Usage: (nil? <value>)",
 	),
 
 	// core macros
@@ -37,37 +41,85 @@ var core = []mapEntry{
 	entry("global", parens.MacroFunc(Global),
 		"Usage: (global <symbol> expr)",
 	),
+	entry("set!", parens.MacroFunc(Assign),
+		"Updates the value of an existing binding in the nearest scope that",
+		"defines it. Fails if the symbol is not bound.",
+		"Usage: (set! <symbol> expr)",
+	),
 	entry("cond", parens.MacroFunc(Conditional),
 		"Usage: (cond (test1 action1) (test2 action2)...)",
 	),
-	entry("let", parens.MacroFunc(Let),
-		"Usage: (let expr1 expr2 ...)",
+	entry("if", parens.MacroFunc(If),
+		"Evaluates then if test is truthy (not nil or false), else otherwise",
+		"Usage: (if test then [else])",
+	),
+	entry("when", parens.MacroFunc(When),
+		"Evaluates the body if test is truthy",
+		"Usage: (when test body...)",
+	),
+	entry("unless", parens.MacroFunc(Unless),
+		"Evaluates the body if test is not truthy",
+		"Usage: (unless test body...)",
 	),
-	entry("inspect", parens.MacroFunc(Inspect),
-		"Usage: (inspect expr)",
+	entry("and", parens.MacroFunc(And),
+		"Returns the first value that is not truthy or the last value",
+		"Usage: (and expr1 expr2 ...)",
+	),
+	entry("or", parens.MacroFunc(Or),
+		"Returns the first truthy value or the last value",
+		"Usage: (or expr1 expr2 ...)",
+	),
+	entry("case", parens.MacroFunc(Case),
+		"Evaluates the result paired with the first constant matching the",
+		"value of expr. Constants are not evaluated and a list of constants",
+		"matches any of them.",
+		"Usage: (case expr const1 result1 (const2 const3) result2 ... [default])",
+	),
+	entry("let", parens.MacroFunc(Let),
+		"Usage: (let expr1 expr2 ...) or (let [pattern1 val1 ...] expr1 ...)",
 	),
 	entry("lambda", parens.MacroFunc(Lambda),
 		"Defines a lambda.",
-		"Usage: (lambda (params) body)",
-		"where params: a list of symbols",
-		"      body  : one or more s-expressions",
+		"Usage: (lambda [params] body) or (lambda ([params] body) ...)",
+		"where params: symbols or destructuring patterns, optionally",
+		"              ending with '& rest'",
+		"      body  : zero or more s-expressions (empty body returns nil)",
 	),
 	entry("defn", parens.MacroFunc(Defn),
-		"Defines a named function",
-		"Usage: (defn <name> [params] body)",
+		"Defines a named function with an optional doc string",
+		"Usage: (defn <name> [doc] [params] body) or (defn <name> [doc] ([params] body) ...)",
 	),
 	entry("doc", parens.MacroFunc(Doc),
 		"Displays documentation for given symbol if available.",
 		"Usage: (doc <symbol>)",
 	),
 	entry("dump-scope", parens.MacroFunc(dumpScope),
-		"Formats and displays the entire scope",
+		"Returns the sorted list of names visible in the current scope",
+	),
+	entry("loop", parens.MacroFunc(Loop),
+		"Evaluates body with the bindings. Body can use recur to re-evaluate",
+		"itself with new values for the bindings without growing the stack.",
+		"Usage: (loop [name1 val1 name2 val2 ...] body)",
+	),
+	entry("recur", parens.MacroFunc(Recur),
+		"Re-evaluates the enclosing loop or function with new values.",
+		"Must be used in tail position.",
+		"Usage: (recur val1 val2 ...)",
 	),
 	entry("->", parens.MacroFunc(ThreadFirst)),
 	entry("->>", parens.MacroFunc(ThreadLast)),
 
 	// core functions
 	entry("type", reflect.TypeOf),
+	entry("new", newInstance,
+		"Creates a new instance of a Go type and returns pointer to it",
+		"Fields of structs can be initialized using name-value pairs",
+		"Usage: (new <type> :Field1 val1 :Field2 val2 ...)",
+	),
+	entry("set-field!", setField,
+		"Sets the exported field of a pointer to Go struct",
+		"Usage: (set-field! <obj> :Field val)",
+	),
 }
 
 // Quote prevents the expr from being executed until unquoted.
@@ -79,34 +131,15 @@ func Quote(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
 	return exprs[0], nil
 }
 
-// LoadFile returns function that reads and executes a lisp file.
-func LoadFile(env parens.Scope) func(file string) interface{} {
-	return func(file string) interface{} {
-		fh, err := os.Open(file)
-		if err != nil {
-			panic(err)
-		}
-		defer fh.Close()
-		rd := bufio.NewReader(fh)
-
-		val, err := parens.Execute(rd, env)
-		if err != nil {
-			panic(err)
-		}
-
-		return val
-	}
-}
-
 // Eval returns the (eval <val>) function.
-func Eval(env parens.Scope) func(val interface{}) interface{} {
-	return func(val interface{}) interface{} {
+func Eval(env parens.Scope) func(ctx context.Context, val interface{}) interface{} {
+	return func(ctx context.Context, val interface{}) interface{} {
 		expr, ok := val.(parens.Expr)
 		if !ok {
 			return val
 		}
 
-		res, err := expr.Eval(env)
+		res, err := expr.Eval(parens.WithContext(ctx, env))
 		if err != nil {
 			panic(err)
 		}
@@ -132,34 +165,36 @@ func thread(first bool, scope parens.Scope, exprs []parens.Expr) (interface{}, e
 		return nil, fmt.Errorf("at-least 1 argument required")
 	}
 
-	var result interface{}
+	result, err := exprs[0].Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+
 	for i := 1; i < len(exprs); i++ {
 		lst, ok := exprs[i].(parens.List)
-		if !ok {
+		if !ok || len(lst.Forms) == 0 {
 			return nil, fmt.Errorf("argument %d must be a function call, not '%s'", i, reflect.TypeOf(exprs[i]))
 		}
 
-		val, err := exprs[i-1].Eval(scope)
-		if err != nil {
-			return nil, err
+		res := anyExpr{val: result}
+		nextCall := parens.List{
+			Position: lst.Position,
+			Forms:    make([]parens.Expr, 0, len(lst.Forms)+1),
 		}
-		res := anyExpr{val: val}
-
-		nextCall := parens.List([]parens.Expr{lst[0]})
+		nextCall.Forms = append(nextCall.Forms, lst.Forms[0])
 
 		if first {
-			nextCall = append(nextCall, res)
-			nextCall = append(nextCall, lst[1:]...)
+			nextCall.Forms = append(nextCall.Forms, res)
+			nextCall.Forms = append(nextCall.Forms, lst.Forms[1:]...)
 		} else {
-			nextCall = append(nextCall, lst[1:]...)
-			nextCall = append(nextCall, res)
+			nextCall.Forms = append(nextCall.Forms, lst.Forms[1:]...)
+			nextCall.Forms = append(nextCall.Forms, res)
 		}
 
 		result, err = nextCall.Eval(scope)
 		if err != nil {
 			return nil, err
 		}
-		exprs[i] = anyExpr{val: result}
 	}
 
 	return result, nil
@@ -173,6 +208,8 @@ func (ae anyExpr) Eval(scope parens.Scope) (interface{}, error) {
 	return ae.val, nil
 }
 
+func (ae anyExpr) String() string { return fmt.Sprintf("%v", ae.val) }
+
 // Doc shows doc string associated with a symbol. If not found, returns a message.
 func Doc(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
 	if len(exprs) != 1 {
@@ -189,23 +226,43 @@ func Doc(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
 		return nil, err
 	}
 
-	var docStr string
-	if swd, ok := scope.(scopeWithDoc); ok {
-		docStr = swd.Doc(string(sym))
+	var meta parens.Meta
+	if ns, name, ok := parens.LookupQualified(scope, sym.Value); ok {
+		meta, _ = ns.Meta(name)
+	} else if is, ok := scope.(parens.IntrospectableScope); ok {
+		meta, _ = is.Meta(sym.Value)
+	} else if swd, ok := scope.(scopeWithDoc); ok {
+		meta.Doc = swd.Doc(sym.Value)
 	}
+
+	docStr := meta.Doc
 	if len(strings.TrimSpace(docStr)) == 0 {
-		docStr = fmt.Sprintf("No documentation available for '%s'", string(sym))
+		docStr = fmt.Sprintf("No documentation available for '%s'", sym.Value)
+	}
+	docStr += "\n"
+
+	if fn, ok := val.(*Fn); ok && len(meta.Arglists) == 0 {
+		meta.Arglists = fn.Arglists()
+	}
+	if len(meta.Arglists) > 0 {
+		docStr += fmt.Sprintf("\nArglists: %s", strings.Join(meta.Arglists, " "))
+	}
+	if meta.Deprecated != "" {
+		docStr += fmt.Sprintf("\nDeprecated: %s", meta.Deprecated)
+	}
+	if !meta.Pos.IsZero() {
+		docStr += fmt.Sprintf("\nDefined at: %s", meta.Pos)
 	}
 
-	docStr = fmt.Sprintf("%s\n\nGo Type: %s", docStr, reflect.TypeOf(val))
+	docStr = fmt.Sprintf("%s\nGo Type: %s", docStr, reflect.TypeOf(val))
 	return docStr, nil
 }
 
 // Defn macro is for defining named functions. It defines a lambda and binds it with
 // the given name into the scope.
 func Defn(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
-	if len(exprs) < 3 {
-		return nil, fmt.Errorf("3 or more arguments required, got %d", len(exprs))
+	if len(exprs) < 2 {
+		return nil, fmt.Errorf("2 or more arguments required, got %d", len(exprs))
 	}
 
 	sym, ok := exprs[0].(parens.Symbol)
@@ -213,55 +270,91 @@ func Defn(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
 		return nil, fmt.Errorf("first argument must be symbol, not '%s'", reflect.TypeOf(exprs[0]))
 	}
 
+	var doc string
+	if str, ok := exprs[1].(parens.String); ok && len(exprs) > 2 && isParamsOrArity(exprs[2]) {
+		doc, exprs = string(str), append([]parens.Expr{exprs[0]}, exprs[2:]...)
+	}
+
 	lambda, err := Lambda(scope, exprs[1:])
 	if err != nil {
 		return nil, err
 	}
+	fn := lambda.(*Fn)
+	fn.Name = sym.Value
 
-	scope.Bind(string(sym), lambda)
-	return string(sym), nil
+	if err := bindMeta(scope, sym.Value, fn, parens.Meta{Doc: doc, Pos: sym.Position}); err != nil {
+		return nil, err
+	}
+	return sym.Value, nil
 }
 
-// Lambda macro is for defining lambdas. (lambda (params) body)
+// Lambda macro is for defining lambdas. Params can be symbols or
+// destructuring patterns and may end with '& rest'. Multiple arities can
+// be defined using one (params body...) list per arity.
 func Lambda(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
-	if len(exprs) < 2 {
-		return nil, errors.New("at-least two arguments required")
+	if len(exprs) < 1 {
+		return nil, errors.New("at-least 1 argument required")
 	}
 
-	paramList, ok := exprs[0].(parens.Vector)
-	if !ok {
-		return nil, fmt.Errorf("first argument must be list of symbols, not '%s'", reflect.TypeOf(exprs[0]))
-	}
-
-	params := []string{}
-	for _, entry := range paramList {
-		sym, ok := entry.(parens.Symbol)
-		if !ok {
-			return nil, fmt.Errorf("param list must contain symbols, not '%s'", reflect.TypeOf(entry))
+	if _, ok := exprs[0].(parens.Vector); ok {
+		arity, err := parseArity(exprs)
+		if err != nil {
+			return nil, err
 		}
 
-		params = append(params, string(sym))
+		return &Fn{arities: []fnArity{arity}, scope: scope}, nil
 	}
 
-	lambdaFunc := func(args ...interface{}) interface{} {
-		if len(params) != len(args) {
-			panic(fmt.Errorf("requires %d arguments, got %d", len(params), len(args)))
+	fn := &Fn{scope: scope}
+	for _, expr := range exprs {
+		clause, ok := expr.(parens.List)
+		if !ok || len(clause.Forms) < 1 {
+			return nil, fmt.Errorf("arity must be a list of params vector and body, not '%s'", expr)
 		}
 
-		localScope := parens.NewScope(scope)
-		for i := range params {
-			localScope.Bind(params[i], args[i])
+		arity, err := parseArity(clause.Forms)
+		if err != nil {
+			return nil, err
 		}
 
-		val, err := Do(localScope, exprs[1:])
-		if err != nil {
-			panic(err)
+		for _, other := range fn.arities {
+			if arity.params.rest != nil && other.params.rest != nil {
+				return nil, errors.New("only one variadic arity is allowed")
+			} else if len(arity.params.fixed) == len(other.params.fixed) && arity.params.rest == other.params.rest {
+				return nil, fmt.Errorf("multiple arities with %d params", len(arity.params.fixed))
+			}
 		}
+		fn.arities = append(fn.arities, arity)
+	}
 
-		return val
+	return fn, nil
+}
+
+// isParamsOrArity returns true if the form is a params vector or an arity
+// list of a function definition.
+func isParamsOrArity(form parens.Expr) bool {
+	switch form.(type) {
+	case parens.Vector, parens.List:
+		return true
+	}
+	return false
+}
+
+func parseArity(forms []parens.Expr) (fnArity, error) {
+	paramList, ok := forms[0].(parens.Vector)
+	if !ok {
+		return fnArity{}, fmt.Errorf("params must be a vector, not '%s'", reflect.TypeOf(forms[0]))
 	}
 
-	return lambdaFunc, nil
+	params, err := parseParams(paramList)
+	if err != nil {
+		return fnArity{}, err
+	}
+
+	return fnArity{
+		params: params,
+		body:   markTailBody(forms[1:], markTail),
+	}, nil
 }
 
 // Do executes all s-exps one by one and returns the result of last evaluation.
@@ -280,11 +373,41 @@ func Do(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
 
 // Let creates a new sub-scope from the global scope and executes all the
 // exprs inside the new scope. Once the Let block ends, all the names bound
-// will be removed. In other words, Let is a Do with local scope.
+// will be removed. In other words, Let is a Do with local scope. If the
+// first argument is a vector, it is treated as pattern-value pairs which
+// are evaluated and destructured into the local scope in order.
 func Let(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
 	localScope := parens.NewScope(scope)
 
-	return Do(localScope, exprs)
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+
+	bindings, ok := exprs[0].(parens.Vector)
+	if !ok {
+		return Do(localScope, exprs)
+	}
+
+	if len(bindings.Forms)%2 != 0 {
+		return nil, errors.New("bindings must be a vector of pattern-value pairs")
+	}
+
+	for i := 0; i < len(bindings.Forms); i += 2 {
+		if err := checkPattern(bindings.Forms[i]); err != nil {
+			return nil, err
+		}
+
+		val, err := bindings.Forms[i+1].Eval(localScope)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := bindPattern(localScope, bindings.Forms[i], val); err != nil {
+			return nil, err
+		}
+	}
+
+	return Do(localScope, exprs[1:])
 }
 
 // Conditional is commonly know LISP (cond (test1 act1)...) construct.
@@ -298,27 +421,21 @@ func Conditional(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
 		if !ok {
 			return nil, errors.New("all arguments must be lists")
 		}
-		if len(listExp) != 2 {
+		if len(listExp.Forms) != 2 {
 			return nil, errors.New("each argument must be of the form (test action)")
 		}
 		lists = append(lists, listExp)
 	}
 
 	for _, list := range lists {
-		testResult, err := list[0].Eval(scope)
+		ok, err := evalTest(scope, list.Forms[0])
 		if err != nil {
 			return nil, err
 		}
 
-		if testResult == nil {
-			continue
+		if ok {
+			return list.Forms[1].Eval(scope)
 		}
-
-		if resultBool, ok := testResult.(bool); ok && resultBool == false {
-			continue
-		}
-
-		return list[1].Eval(scope)
 	}
 
 	return nil, nil
@@ -336,13 +453,61 @@ func Global(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
 	return labelInScope(scope.Root(), exprs)
 }
 
-// Inspect dumps the exprs in a formatted manner.
-func Inspect(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
-	pp.Println(exprs)
-	return nil, nil
+// Assign updates the value bound to the symbol passed in as first argument
+// with the result of evaluating second argument. The binding is updated in
+// the nearest scope that defines it. For qualified symbols of the form
+// ns/name, the binding of name in the namespace itself is updated.
+func Assign(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	if len(exprs) != 2 {
+		return nil, fmt.Errorf("expecting symbol and a value")
+	}
+	symbol, ok := exprs[0].(parens.Symbol)
+	if !ok {
+		return nil, fmt.Errorf("argument 1 must be a symbol, not '%s'", reflect.TypeOf(exprs[0]).String())
+	}
+
+	name := symbol.Value
+	ms, ok := scope.(parens.MutableScope)
+	if ns, nsName, isQualified := parens.LookupQualified(scope, name); isQualified {
+		if _, err := ns.Own(nsName); err != nil {
+			return nil, err
+		}
+		ms, ok, name = ns, true, nsName
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("scope of type '%s' does not support set!", reflect.TypeOf(scope))
+	}
+
+	val, err := exprs[1].Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ms.Set(name, val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+func newInstance(ctx context.Context, rType reflect.Type, args ...interface{}) (interface{}, error) {
+	return parens.NewInstance(ctx, rType, args...)
+}
+
+func setField(ctx context.Context, obj interface{}, name interface{}, val interface{}) (interface{}, error) {
+	fieldName, err := parens.MemberName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return parens.SetField(ctx, obj, fieldName, val)
 }
 
 func dumpScope(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	if is, ok := scope.(parens.IntrospectableScope); ok {
+		return strings.Join(is.Names(), "\n"), nil
+	}
+
 	return fmt.Sprint(scope), nil
 }
 
@@ -360,11 +525,26 @@ func labelInScope(scope parens.Scope, exprs []parens.Expr) (interface{}, error)
 		return nil, err
 	}
 
-	scope.Bind(string(symbol), val)
+	if err := bindMeta(scope, symbol.Value, val, parens.Meta{Pos: symbol.Position}); err != nil {
+		return nil, err
+	}
 
 	return val, nil
 }
 
+// bindMeta binds the value with the metadata if the scope supports it. Arglists
+// of functions are added to the metadata.
+func bindMeta(scope parens.Scope, name string, val interface{}, meta parens.Meta) error {
+	if fn, ok := val.(*Fn); ok {
+		meta.Arglists = fn.Arglists()
+	}
+
+	if is, ok := scope.(parens.IntrospectableScope); ok {
+		return is.BindMeta(name, val, meta)
+	}
+	return scope.Bind(name, val, meta.Doc)
+}
+
 type scopeWithDoc interface {
 	Doc(name string) string
 }
@@ -0,0 +1,136 @@
+package stdlib_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spy16/parens"
+	"github.com/spy16/parens/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeForms(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		want    interface{}
+		wantErr string
+	}{
+		{name: "Set", src: `(label x 1) (set! x 2) x`, want: int64(2)},
+		{name: "SetReturnsValue", src: `(label x 1) (set! x "new")`, want: "new"},
+		{name: "SetFromFn", src: `(label n 0) (defn incr [] (set! n (+ n 1))) (incr) (incr) n`, want: float64(2)},
+		{name: "SetLocal", src: `(label x 1) [(let [x 10] (set! x 20) x) x]`, want: []interface{}{int64(20), int64(1)}},
+		{name: "SetUnbound", src: `(set! y 1)`, wantErr: "name 'y' not found"},
+		{name: "SetNotSymbol", src: `(set! "y" 1)`, wantErr: "argument 1 must be a symbol, not 'parens.String'"},
+		{name: "SetArgs", src: `(set! y)`, wantErr: "expecting symbol and a value"},
+		{name: "DefnDocString", src: `(defn sq "squares x" [x] (* x x)) (sq 3)`, want: float64(9)},
+		{name: "DefnStringBody", src: `(defn greet [] "hello") (greet)`, want: "hello"},
+		{name: "DefnDocStringEmptyBody", src: `(defn f "does nothing" [x]) (f 1)`, want: nil},
+		{name: "DefnDocStringArities", src: `(defn f "doc" ([] 0) ([x] x)) [(f) (f 1)]`, want: []interface{}{int64(0), int64(1)}},
+		{name: "DefnStringOnly", src: `(defn f "hello")`, wantErr: "arity must be a list of params vector and body, not '\"hello\"'"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasSuffix(err.Error(), tt.wantErr), "unexpected error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, parens.ToGo(got))
+		})
+	}
+}
+
+func TestThreading(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		want    interface{}
+		wantErr string
+	}{
+		{name: "First", src: `(-> 10 (- 4) (/ 2))`, want: float64(3)},
+		{name: "Last", src: `(->> 10 (- 4) (* 2))`, want: float64(-12)},
+		{name: "OnlyValue", src: `(-> 5)`, want: int64(5)},
+		{name: "CalledTwice", src: `(defn f [x] (-> x (+ 1) (* 2))) [(f 1) (f 5)]`, want: []interface{}{float64(4), float64(12)}},
+		{name: "NotACall", src: `(-> 1 2)`, wantErr: "argument 1 must be a function call, not 'parens.Int64'"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasSuffix(err.Error(), tt.wantErr), "unexpected error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, parens.ToGo(got))
+		})
+	}
+}
+
+func TestDoc(t *testing.T) {
+	t.Parallel()
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+	require.NoError(t, scope.(parens.IntrospectableScope).BindMeta("old-add", func(a, b int) int { return a + b }, parens.Meta{
+		Doc:        "Adds two numbers",
+		Deprecated: "use + instead",
+	}))
+
+	got, err := parens.ExecuteStr("(defn sq \"Squares x\" ([x] (* x x)) ([x & more] x))\n(doc sq)", scope)
+	require.NoError(t, err)
+	assert.Equal(t, "Squares x\n\nArglists: [x] [x & more]\nDefined at: <string>:1:7\nGo Type: *stdlib.Fn", got)
+
+	got, err = parens.ExecuteStr(`(doc old-add)`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, "Adds two numbers\n\nDeprecated: use + instead\nGo Type: func(int, int) int", got)
+
+	got, err = parens.ExecuteStr(`(defn f "Identity" [x] x) (set! f 1) (doc f)`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, "Identity\n\nDefined at: <string>:1:7\nGo Type: int64", got)
+
+	got, err = parens.ExecuteStr(`(defn g [x] x) (set! g (lambda [a b] a)) (doc g)`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, "No documentation available for 'g'\n\nArglists: [a b]\nDefined at: <string>:1:7\nGo Type: *stdlib.Fn", got)
+
+	got, err = parens.ExecuteStr(`(label x 1) (doc x)`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, "No documentation available for 'x'\n\nDefined at: <string>:1:8\nGo Type: int64", got)
+}
+
+func TestDumpScope(t *testing.T) {
+	t.Parallel()
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+
+	got, err := parens.ExecuteStr(`(let [zzz 1 aaa 2] (dump-scope))`, scope)
+	require.NoError(t, err)
+
+	names := strings.Split(got.(string), "\n")
+	assert.True(t, sort.StringsAreSorted(names))
+	assert.Contains(t, names, "aaa")
+	assert.Contains(t, names, "zzz")
+	assert.Contains(t, names, "set!")
+}
@@ -0,0 +1,343 @@
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spy16/parens"
+)
+
+// params represents the parameter list of a function or a macro. Each of
+// the params can be a symbol or a destructuring pattern and the list may
+// end with '& rest' to collect the remaining arguments.
+type params struct {
+	fixed []parens.Expr
+	rest  parens.Expr
+}
+
+func parseParams(vec parens.Vector) (params, error) {
+	var p params
+	for i := 0; i < len(vec.Forms); i++ {
+		if isSymbol(vec.Forms[i], "&") {
+			if i != len(vec.Forms)-2 {
+				return p, errors.New("'&' must be followed by exactly one param")
+			}
+
+			if err := checkPattern(vec.Forms[i+1]); err != nil {
+				return p, err
+			}
+			p.rest = vec.Forms[i+1]
+			break
+		}
+
+		if err := checkPattern(vec.Forms[i]); err != nil {
+			return p, err
+		}
+		p.fixed = append(p.fixed, vec.Forms[i])
+	}
+
+	return p, nil
+}
+
+// accepts returns true if the params can be bound to n arguments.
+func (p params) accepts(n int) bool {
+	return n == len(p.fixed) || (p.rest != nil && n > len(p.fixed))
+}
+
+// bind binds the args to the params in the scope. Remaining args are bound
+// to the rest param as a vector.
+func (p params) bind(scope parens.Scope, args []interface{}) error {
+	if !p.accepts(len(args)) {
+		return fmt.Errorf("%w: requires %s, got %d", parens.ErrInvalidNumberOfArgs, p.arity(), len(args))
+	}
+
+	for i, param := range p.fixed {
+		if err := bindPattern(scope, param, args[i]); err != nil {
+			return err
+		}
+	}
+
+	if p.rest != nil {
+		return bindPattern(scope, p.rest, parens.NewVector(args[len(p.fixed):]...))
+	}
+
+	return nil
+}
+
+// bindRecur binds the args of recur to the params. Unlike bind, value for
+// the rest param is passed as a single sequence argument.
+func (p params) bindRecur(scope parens.Scope, args []interface{}) error {
+	if p.rest == nil {
+		return p.bind(scope, args)
+	}
+
+	if len(args) != len(p.fixed)+1 {
+		return fmt.Errorf("%w: recur requires %d arguments, got %d",
+			parens.ErrInvalidNumberOfArgs, len(p.fixed)+1, len(args))
+	}
+
+	for i, param := range p.fixed {
+		if err := bindPattern(scope, param, args[i]); err != nil {
+			return err
+		}
+	}
+	return bindPattern(scope, p.rest, args[len(p.fixed)])
+}
+
+// String formats the params as a params vector.
+func (p params) String() string {
+	parts := make([]string, 0, len(p.fixed)+2)
+	for _, param := range p.fixed {
+		parts = append(parts, fmt.Sprint(param))
+	}
+
+	if p.rest != nil {
+		parts = append(parts, "&", fmt.Sprint(p.rest))
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func (p params) arity() string {
+	if p.rest != nil {
+		return fmt.Sprintf("at-least %d arguments", len(p.fixed))
+	}
+
+	return fmt.Sprintf("%d arguments", len(p.fixed))
+}
+
+// checkPattern validates a binding pattern. A pattern can be a symbol, a
+// vector pattern (e.g., [a b & rest :as all]) or a map pattern (e.g.,
+// {:keys [a b] :strs [c] :or {a 1} :as m} or {x :x}).
+func checkPattern(pattern parens.Expr) error {
+	switch p := pattern.(type) {
+	case parens.Symbol:
+		if p.Value == "&" {
+			return errors.New("'&' must be followed by exactly one param")
+		}
+		return nil
+
+	case parens.Vector:
+		for i := 0; i < len(p.Forms); i++ {
+			switch {
+			case isSymbol(p.Forms[i], "&"):
+				if i+1 >= len(p.Forms) || isKeyword(p.Forms[i+1], ":as") {
+					return errors.New("'&' must be followed by exactly one pattern")
+				}
+				if err := checkPattern(p.Forms[i+1]); err != nil {
+					return err
+				}
+				i++
+
+			case isKeyword(p.Forms[i], ":as"):
+				if i != len(p.Forms)-2 {
+					return errors.New("':as' must be followed by a symbol at the end")
+				} else if _, ok := p.Forms[i+1].(parens.Symbol); !ok {
+					return errors.New("':as' must be followed by a symbol at the end")
+				}
+				i++
+
+			default:
+				if err := checkPattern(p.Forms[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	case parens.HashMap:
+		for i, key := range p.Keys {
+			val := p.Values[i]
+			switch {
+			case isKeyword(key, ":keys"), isKeyword(key, ":strs"):
+				names, ok := val.(parens.Vector)
+				if !ok {
+					return fmt.Errorf("'%s' must be followed by a vector of symbols", key)
+				}
+				for _, name := range names.Forms {
+					if _, ok := name.(parens.Symbol); !ok {
+						return fmt.Errorf("'%s' must be followed by a vector of symbols", key)
+					}
+				}
+
+			case isKeyword(key, ":as"):
+				if _, ok := val.(parens.Symbol); !ok {
+					return errors.New("':as' must be followed by a symbol")
+				}
+
+			case isKeyword(key, ":or"):
+				if _, ok := val.(parens.HashMap); !ok {
+					return errors.New("':or' must be followed by a map of defaults")
+				}
+
+			default:
+				if err := checkPattern(key); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("invalid binding pattern '%s'", reflect.TypeOf(pattern))
+	}
+}
+
+// bindPattern destructures the value according to the pattern and binds
+// the names into the scope. Missing values are bound to nil.
+func bindPattern(scope parens.Scope, pattern parens.Expr, val interface{}) error {
+	switch p := pattern.(type) {
+	case parens.Symbol:
+		return scope.Bind(p.Value, val)
+
+	case parens.Vector:
+		return bindSeq(scope, p, val)
+
+	case parens.HashMap:
+		return bindMap(scope, p, val)
+
+	default:
+		return fmt.Errorf("invalid binding pattern '%s'", reflect.TypeOf(pattern))
+	}
+}
+
+func bindSeq(scope parens.Scope, pattern parens.Vector, val interface{}) error {
+	s, err := seqOf(val)
+	if err != nil {
+		return fmt.Errorf("cannot destructure '%s' as sequence", reflect.TypeOf(val))
+	}
+
+	ctx := parens.ContextOf(scope)
+	for i := 0; i < len(pattern.Forms); i++ {
+		switch {
+		case isSymbol(pattern.Forms[i], "&"):
+			var rest interface{} = s
+			if items, ok := s.([]interface{}); ok || s == nil {
+				rest = parens.NewVector(items...)
+			}
+			s = nil
+
+			if err := bindPattern(scope, pattern.Forms[i+1], rest); err != nil {
+				return err
+			}
+			i++
+
+		case isKeyword(pattern.Forms[i], ":as"):
+			if err := bindPattern(scope, pattern.Forms[i+1], val); err != nil {
+				return err
+			}
+			i++
+
+		default:
+			item, rest, _, err := uncons(ctx, s)
+			if err != nil {
+				return err
+			}
+			s = rest
+
+			if err := bindPattern(scope, pattern.Forms[i], item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func bindMap(scope parens.Scope, pattern parens.HashMap, val interface{}) error {
+	pm, isPersistent := val.(*parens.PersistentMap)
+	rv := reflect.ValueOf(val)
+	if val != nil && !isPersistent && rv.Kind() != reflect.Map {
+		return fmt.Errorf("cannot destructure '%s' as map", reflect.TypeOf(val))
+	}
+
+	defaults := map[string]parens.Expr{}
+	for i, key := range pattern.Keys {
+		if isKeyword(key, ":or") {
+			or := pattern.Values[i].(parens.HashMap)
+			for j, name := range or.Keys {
+				if sym, ok := name.(parens.Symbol); ok {
+					defaults[sym.Value] = or.Values[j]
+				}
+			}
+		}
+	}
+
+	lookup := func(target parens.Expr, key interface{}) (interface{}, error) {
+		if isPersistent {
+			if v, found := pm.Get(key); found {
+				return v, nil
+			}
+		} else if val != nil {
+			if kv, ok := mapKey(rv, key); ok {
+				if v := rv.MapIndex(kv); v.IsValid() {
+					return v.Interface(), nil
+				}
+			}
+		}
+
+		if sym, ok := target.(parens.Symbol); ok {
+			if def, found := defaults[sym.Value]; found {
+				return def.Eval(scope)
+			}
+		}
+		return nil, nil
+	}
+
+	bindKeyed := func(target parens.Expr, key interface{}) error {
+		v, err := lookup(target, key)
+		if err != nil {
+			return err
+		}
+		return bindPattern(scope, target, v)
+	}
+
+	for i, key := range pattern.Keys {
+		switch {
+		case isKeyword(key, ":keys"), isKeyword(key, ":strs"):
+			for _, name := range pattern.Values[i].(parens.Vector).Forms {
+				sym := name.(parens.Symbol)
+
+				var k interface{} = sym.Value
+				if isKeyword(key, ":keys") {
+					k = parens.Keyword(":" + sym.Value)
+				}
+
+				if err := bindKeyed(sym, k); err != nil {
+					return err
+				}
+			}
+
+		case isKeyword(key, ":as"):
+			if err := bindPattern(scope, pattern.Values[i], val); err != nil {
+				return err
+			}
+
+		case isKeyword(key, ":or"):
+			continue
+
+		default:
+			k, err := pattern.Values[i].Eval(scope)
+			if err != nil {
+				return err
+			}
+
+			if err := bindKeyed(key, k); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func isSymbol(expr parens.Expr, name string) bool {
+	sym, ok := expr.(parens.Symbol)
+	return ok && sym.Value == name
+}
+
+func isKeyword(expr parens.Expr, name string) bool {
+	kw, ok := expr.(parens.Keyword)
+	return ok && string(kw) == name
+}
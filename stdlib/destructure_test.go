@@ -0,0 +1,147 @@
+package stdlib_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spy16/parens"
+	"github.com/spy16/parens/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestructuring(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		want    interface{}
+		wantErr string
+	}{
+		{
+			name: "VariadicFn",
+			src:  `((lambda [a & rest] [a rest]) 1 2 3)`,
+			want: []interface{}{int64(1), []interface{}{int64(2), int64(3)}},
+		},
+		{
+			name: "VariadicFnNoRest",
+			src:  `((lambda [a & rest] rest) 1)`,
+			want: []interface{}{},
+		},
+		{
+			name: "VectorPattern",
+			src:  `((lambda [[a [b c] & more :as all]] [a c more all]) [1 [2 3] 4])`,
+			want: []interface{}{
+				int64(1), int64(3),
+				[]interface{}{int64(4)},
+				[]interface{}{int64(1), []interface{}{int64(2), int64(3)}, int64(4)},
+			},
+		},
+		{
+			name: "VectorPatternMissing",
+			src:  `(let [[a b] [1]] [a b])`,
+			want: []interface{}{int64(1), nil},
+		},
+		{
+			name: "MapPattern",
+			src: `(let [{:keys [name age] :strs [city] :or {age 18} :as m} {:name "bob" "city" "x"}
+                        {n :name} m]
+                    [name age city n])`,
+			want: []interface{}{"bob", int64(18), "x", "bob"},
+		},
+		{
+			name: "LetSequential",
+			src:  `(let [a 1 [b] [a]] b)`,
+			want: int64(1),
+		},
+		{
+			name: "LetWithoutBindings",
+			src:  `(let (label x 1) x)`,
+			want: int64(1),
+		},
+		{
+			name: "MultiArity",
+			src: `(defn greet
+                    ([] (greet "world"))
+                    ([name] (greet "hello" name))
+                    ([greeting name & _] [greeting name]))
+                  [(greet) (greet "bob") (greet "hi" "bob" "extra")]`,
+			want: []interface{}{
+				[]interface{}{"hello", "world"},
+				[]interface{}{"hello", "bob"},
+				[]interface{}{"hi", "bob"},
+			},
+		},
+		{
+			name: "RecurVariadic",
+			src: `(defn sum [acc & nums]
+                    (cond
+                      ((= (count nums) 0) acc)
+                      (true (recur (+ acc (first nums)) (rest nums)))))
+                  (sum 0 1 2 3)`,
+			want: float64(6),
+		},
+		{
+			name: "MacroDestructuring",
+			src: `(defmacro my-when [test & [first-form :as body]] ` + "`" + `(cond (~test (do ~@body))))
+                  (my-when true 1 2)`,
+			want: int64(2),
+		},
+		{
+			name:    "ArityError",
+			src:     `(defn f [a b] a) (f 1)`,
+			wantErr: "invalid number of arguments: <fn: f> requires 2 arguments, got 1",
+		},
+		{
+			name:    "MultiArityError",
+			src:     `(defn f ([] 0) ([a] a)) (f 1 2)`,
+			wantErr: "invalid number of arguments: <fn: f> does not accept 2 arguments",
+		},
+		{
+			name:    "InvalidPattern",
+			src:     `(lambda [1] 1)`,
+			wantErr: "invalid binding pattern 'parens.Int64'",
+		},
+		{
+			name:    "InvalidRest",
+			src:     `(lambda [a & b c] 1)`,
+			wantErr: "'&' must be followed by exactly one param",
+		},
+		{
+			name:    "NotASequence",
+			src:     `((lambda [[a]] a) 1)`,
+			wantErr: "cannot destructure 'int64' as sequence",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+			scope.Bind("=", func(a, b interface{}) bool { return a == b })
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasSuffix(err.Error(), tt.wantErr), "unexpected error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, parens.ToGo(got))
+		})
+	}
+}
+
+func TestFn_ArityErrorIs(t *testing.T) {
+	t.Parallel()
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+
+	_, err := parens.ExecuteStr(`(defn f [a] a) (f)`, scope)
+	assert.True(t, errors.Is(err, parens.ErrInvalidNumberOfArgs), "unexpected error: %v", err)
+}
@@ -0,0 +1,275 @@
+package stdlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/spy16/parens"
+)
+
+var exceptions = []mapEntry{
+	entry("try", parens.MacroFunc(Try),
+		"Evaluates the body and handles the errors using the first matching",
+		"catch clause. Forms in finally clause are always evaluated.",
+		"Matcher can be :default (catches all), a keyword matching :type in",
+		"data of ex-info errors, an error value (compared using errors.Is)",
+		"or a Go error type (compared using the error chain).",
+		"Usage: (try body... (catch <matcher> <name> handler...) (finally forms...))",
+	),
+	entry("throw", parens.MacroFunc(Throw),
+		"Raises the error. Strings are converted to errors.",
+		"Usage: (throw (ex-info \"failed\" {:type :my-error}))",
+	),
+	entry("ex-info", exInfo,
+		"Creates an error with a message, a data map and an optional cause",
+		"Usage: (ex-info <message> <data> [cause])",
+	),
+	entry("ex-message", exMessage,
+		"Returns the message of the error",
+		"Usage: (ex-message <error>)",
+	),
+	entry("ex-data", exData,
+		"Returns the data map of ex-info error. Returns nil for other errors",
+		"Usage: (ex-data <error>)",
+	),
+}
+
+// ExInfo is an error carrying a message and a map of data created using
+// ex-info. Go code can retrieve it from the evaluation errors using
+// errors.As. Data is never nil for errors created using ex-info.
+type ExInfo struct {
+	Message string
+	Data    *parens.PersistentMap
+	Cause   error
+}
+
+func (ex *ExInfo) Error() string {
+	if ex.Cause == nil {
+		return ex.Message
+	}
+
+	return fmt.Sprintf("%s: %v", ex.Message, ex.Cause)
+}
+
+// Unwrap returns the cause of the error.
+func (ex *ExInfo) Unwrap() error { return ex.Cause }
+
+// Try evaluates the body and handles errors using catch and finally clauses.
+// Errors caused by cancellation of the context or by exceeding the limits
+// of the evaluation cannot be caught.
+func Try(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	body, catches, finally, err := parseTry(exprs)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := Do(scope, body)
+	if err != nil && isCatchable(err) {
+		res, err = handleErr(scope, err, catches)
+	}
+
+	if finally != nil {
+		if _, finallyErr := Do(scope, finally.Forms[1:]); finallyErr != nil {
+			return nil, finallyErr
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Throw evaluates the argument and raises it as an error.
+func Throw(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	if len(exprs) != 1 {
+		return nil, fmt.Errorf("exactly 1 argument required, got %d", len(exprs))
+	}
+
+	val, err := exprs[0].Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := val.(type) {
+	case error:
+		return nil, v
+
+	case string:
+		return nil, errors.New(v)
+
+	default:
+		return nil, fmt.Errorf("throw requires an error or string, not '%s'", reflect.TypeOf(val))
+	}
+}
+
+func parseTry(exprs []parens.Expr) (body []parens.Expr, catches []parens.List, finally *parens.List, err error) {
+	for _, expr := range exprs {
+		clause, name := tryClause(expr)
+		switch {
+		case name == "" && (len(catches) > 0 || finally != nil):
+			return nil, nil, nil, errors.New("body forms are not allowed after catch or finally")
+
+		case name == "":
+			body = append(body, expr)
+
+		case name == "catch" && finally != nil:
+			return nil, nil, nil, errors.New("catch is not allowed after finally")
+
+		case name == "catch":
+			if len(clause.Forms) < 3 {
+				return nil, nil, nil, errors.New("catch requires a matcher and a name")
+			} else if _, ok := clause.Forms[2].(parens.Symbol); !ok {
+				return nil, nil, nil, fmt.Errorf("catch binding must be a symbol, not '%s'", reflect.TypeOf(clause.Forms[2]))
+			}
+			catches = append(catches, clause)
+
+		case finally != nil:
+			return nil, nil, nil, errors.New("only one finally is allowed")
+
+		default:
+			finally = &clause
+		}
+	}
+
+	return body, catches, finally, nil
+}
+
+func tryClause(expr parens.Expr) (parens.List, string) {
+	lst, ok := expr.(parens.List)
+	if !ok || len(lst.Forms) == 0 {
+		return lst, ""
+	}
+
+	sym, ok := lst.Forms[0].(parens.Symbol)
+	if !ok || (sym.Value != "catch" && sym.Value != "finally") {
+		return lst, ""
+	}
+
+	return lst, sym.Value
+}
+
+func handleErr(scope parens.Scope, err error, catches []parens.List) (interface{}, error) {
+	thrown := thrownErr(err)
+	for _, clause := range catches {
+		caught, evalErr := matchErr(scope, clause.Forms[1], thrown)
+		if evalErr != nil {
+			return nil, evalErr
+		} else if caught == nil {
+			continue
+		}
+
+		localScope := parens.NewScope(scope)
+		localScope.Bind(clause.Forms[2].(parens.Symbol).Value, caught)
+		return Do(localScope, clause.Forms[3:])
+	}
+
+	return nil, err
+}
+
+// matchErr returns the error to be bound to the name of catch clause if the
+// matcher matches the thrown error.
+func matchErr(scope parens.Scope, matcher parens.Expr, thrown error) (error, error) {
+	if kw, ok := matcher.(parens.Keyword); ok {
+		if kw == ":default" {
+			return thrown, nil
+		}
+
+		var ex *ExInfo
+		if errors.As(thrown, &ex) && ex.Data != nil {
+			if typ, _ := ex.Data.Get(parens.Keyword(":type")); typ == kw {
+				return thrown, nil
+			}
+		}
+		return nil, nil
+	}
+
+	val, err := matcher.Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	switch m := val.(type) {
+	case error:
+		if errors.Is(thrown, m) {
+			return thrown, nil
+		}
+		return nil, nil
+
+	case reflect.Type:
+		for e := thrown; e != nil; e = errors.Unwrap(e) {
+			if t := reflect.TypeOf(e); t == m || (m.Kind() == reflect.Interface && t.Implements(m)) {
+				return e, nil
+			}
+		}
+		return nil, nil
+
+	default:
+		return nil, fmt.Errorf("catch matcher must be a keyword, error or type, not '%s'", reflect.TypeOf(val))
+	}
+}
+
+// thrownErr strips the evaluation errors wrapping the thrown error.
+func thrownErr(err error) error {
+	for {
+		ee, ok := err.(*parens.EvalError)
+		if !ok {
+			return err
+		}
+		err = ee.Cause
+	}
+}
+
+func isCatchable(err error) bool {
+	uncatchable := []error{
+		context.Canceled, context.DeadlineExceeded,
+		parens.ErrStepLimit, parens.ErrDepthLimit, parens.ErrAllocLimit,
+	}
+
+	for _, target := range uncatchable {
+		if errors.Is(err, target) {
+			return false
+		}
+	}
+	return true
+}
+
+func exInfo(msg string, data *parens.PersistentMap, cause ...error) (*ExInfo, error) {
+	if len(cause) > 1 {
+		return nil, fmt.Errorf("at-most 1 cause allowed, got %d", len(cause))
+	}
+
+	if data == nil {
+		data = &parens.PersistentMap{}
+	}
+
+	ex := &ExInfo{
+		Message: msg,
+		Data:    data,
+	}
+	if len(cause) == 1 {
+		ex.Cause = cause[0]
+	}
+
+	return ex, nil
+}
+
+func exMessage(err error) string {
+	var ex *ExInfo
+	if errors.As(err, &ex) {
+		return ex.Message
+	}
+
+	return err.Error()
+}
+
+func exData(err error) *parens.PersistentMap {
+	var ex *ExInfo
+	if errors.As(err, &ex) {
+		return ex.Data
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package stdlib_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spy16/parens"
+	"github.com/spy16/parens/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errSentinel = errors.New("sentinel error")
+
+func TestTry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		want    interface{}
+		wantErr string
+	}{
+		{
+			name: "NoError",
+			src:  `(try (+ 1 2) (catch :default e "failed"))`,
+			want: float64(3),
+		},
+		{
+			name: "CatchDefault",
+			src:  `(try (undefined) (catch :default e (ex-message e)))`,
+			want: "name 'undefined' not found",
+		},
+		{
+			name: "CatchTag",
+			src: `(try
+                    (throw (ex-info "bad input" {:type :validation :field "name"}))
+                    (catch :io e "io")
+                    (catch :validation e (get (ex-data e) :field)))`,
+			want: "name",
+		},
+		{
+			name: "ExDataIsMap",
+			src: `(try
+                    (throw (ex-info "bad input" {:field "name"}))
+                    (catch :default e
+                      (let [data (ex-data e)]
+                        [(== data {:field "name"}) (assoc data :code 1) (contains? data :field)])))`,
+			want: []interface{}{true, map[interface{}]interface{}{parens.Keyword(":field"): "name", parens.Keyword(":code"): int64(1)}, true},
+		},
+		{
+			name: "ExDataNil",
+			src:  `(count (ex-data (ex-info "no data" nil)))`,
+			want: int64(0),
+		},
+		{
+			name: "CatchErrorValue",
+			src:  `(try (fail) (catch :other e "other") (catch sentinel e "sentinel"))`,
+			want: "sentinel",
+		},
+		{
+			name: "CatchErrorType",
+			src:  `(try (open "/non-existent") (catch PathError e (.-Op e)))`,
+			want: "open",
+		},
+		{
+			name:    "NoMatchingCatch",
+			src:     `(try (throw "boom") (catch :validation e "caught"))`,
+			wantErr: "boom",
+		},
+		{
+			name: "Finally",
+			src: `(do
+                    (label cleaned false)
+                    (label res (try 42 (finally (global cleaned true))))
+                    [res cleaned])`,
+			want: []interface{}{int64(42), true},
+		},
+		{
+			name: "FinallyAfterCatch",
+			src: `(do
+                    (label steps 0)
+                    (try
+                      (throw "boom")
+                      (catch :default e (global steps (+ steps 1)))
+                      (finally (global steps (* steps 10))))
+                    steps)`,
+			want: float64(10),
+		},
+		{
+			name:    "ErrorInHandler",
+			src:     `(try (throw "first") (catch :default e (throw "second")))`,
+			wantErr: "second",
+		},
+		{
+			name:    "BodyAfterCatch",
+			src:     `(try (catch :default e 1) 2)`,
+			wantErr: "body forms are not allowed after catch or finally",
+		},
+		{
+			name:    "InvalidThrow",
+			src:     `(throw 1)`,
+			wantErr: "throw requires an error or string, not 'int64'",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+			scope.Bind("sentinel", errSentinel)
+			scope.Bind("fail", func() error { return errSentinel })
+			scope.Bind("open", func(name string) error {
+				_, err := os.Open(name)
+				return err
+			})
+			scope.Bind("PathError", reflect.TypeOf(&os.PathError{}))
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasSuffix(err.Error(), tt.wantErr), "unexpected error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, parens.ToGo(got))
+		})
+	}
+}
+
+func TestTry_Uncatchable(t *testing.T) {
+	t.Parallel()
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+
+	_, err := parens.ExecuteWithOptions(strings.NewReader(`
+		(defn forever [] (forever))
+		(try (forever) (catch :default e "caught"))`), scope, parens.Options{
+		Limits: parens.Limits{MaxSteps: 1000},
+	})
+	assert.True(t, errors.Is(err, parens.ErrStepLimit), "expected step limit error, got %v", err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = parens.ExecuteStrContext(ctx, `(try 1 (catch :default e "caught"))`, scope)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestExInfo_Go(t *testing.T) {
+	t.Parallel()
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+	scope.Bind("sentinel", errSentinel)
+
+	_, err := parens.ExecuteStr(`(throw (ex-info "wrapped" {:code 42} sentinel))`, scope)
+	require.Error(t, err)
+
+	var ex *stdlib.ExInfo
+	require.True(t, errors.As(err, &ex))
+	assert.Equal(t, "wrapped", ex.Message)
+	assert.Equal(t, map[interface{}]interface{}{parens.Keyword(":code"): int64(42)}, ex.Data.ToMap())
+	assert.True(t, errors.Is(err, errSentinel))
+}
@@ -0,0 +1,371 @@
+package stdlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/spy16/parens"
+)
+
+// tailForms maps the code pointers of the macros that evaluate some of
+// their arguments in tail position to a function that marks those arguments
+// using the marker. Macros are identified by their value and not by the
+// name so that rebinding the names does not change the semantics.
+var tailForms map[uintptr]func(forms []parens.Expr, mark marker) []parens.Expr
+
+// marker wraps a form in tail position (see markTail and markRecur).
+type marker func(form parens.Expr) parens.Expr
+
+func init() {
+	tailForms = map[uintptr]func(forms []parens.Expr, mark marker) []parens.Expr{
+		funcPtr(Do):          markTailBody,
+		funcPtr(Let):         markTailBody,
+		funcPtr(Loop):        markTailBody,
+		funcPtr(Conditional): markTailClauses,
+		funcPtr(If):          markTailBranches,
+		funcPtr(When):        markTailAfterTest,
+		funcPtr(Unless):      markTailAfterTest,
+		funcPtr(And):         markTailBody,
+		funcPtr(Or):          markTailBody,
+		funcPtr(Case):        markTailCases,
+	}
+}
+
+// funcPtr returns the code pointer of the function.
+func funcPtr(fn parens.MacroFunc) uintptr { return reflect.ValueOf(fn).Pointer() }
+
+// Fn represents a function defined using lambda or defn. A function can
+// have multiple arities and the one matching the number of arguments is
+// used. Calls made from tail position of the function body do not grow the
+// Go stack.
+type Fn struct {
+	Name    string
+	arities []fnArity
+	scope   parens.Scope
+}
+
+type fnArity struct {
+	params params
+	body   []parens.Expr
+}
+
+// Invoke binds the arguments to params of the matching arity in a new scope
+// and evaluates the body using ctx as the context of evaluation. Tail calls
+// and recur are executed iteratively.
+func (fn *Fn) Invoke(ctx context.Context, args ...interface{}) (interface{}, error) {
+	var recurArity *fnArity
+	for {
+		localScope := parens.WithContext(ctx, parens.NewScope(fn.scope))
+
+		arity := recurArity
+		if arity != nil {
+			if err := arity.params.bindRecur(localScope, args); err != nil {
+				return nil, err
+			}
+		} else {
+			var err error
+			if arity, err = fn.arity(len(args)); err != nil {
+				return nil, err
+			} else if err := arity.params.bind(localScope, args); err != nil {
+				return nil, err
+			}
+		}
+
+		val, err := Do(localScope, arity.body)
+		if err != nil {
+			return nil, err
+		}
+
+		switch next := val.(type) {
+		case *tailCall:
+			fn, args, recurArity = next.fn, next.args, nil
+
+		case *recurCall:
+			args, recurArity = next.args, arity
+
+		default:
+			return val, nil
+		}
+
+		if err := parens.Step(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// arity returns the arity of the function accepting n arguments. Arities
+// with fixed number of params are preferred over the variadic one.
+func (fn *Fn) arity(n int) (*fnArity, error) {
+	var variadic *fnArity
+	for i := range fn.arities {
+		ar := &fn.arities[i]
+		if ar.params.rest == nil && len(ar.params.fixed) == n {
+			return ar, nil
+		} else if ar.params.accepts(n) && variadic == nil {
+			variadic = ar
+		}
+	}
+
+	if variadic != nil {
+		return variadic, nil
+	} else if len(fn.arities) == 1 {
+		return nil, fmt.Errorf("%w: %s requires %s, got %d",
+			parens.ErrInvalidNumberOfArgs, fn, fn.arities[0].params.arity(), n)
+	}
+
+	return nil, fmt.Errorf("%w: %s does not accept %d arguments", parens.ErrInvalidNumberOfArgs, fn, n)
+}
+
+// Arglists returns the params vectors of all the arities of the function.
+func (fn *Fn) Arglists() []string {
+	res := make([]string, len(fn.arities))
+	for i, arity := range fn.arities {
+		res[i] = arity.params.String()
+	}
+	return res
+}
+
+func (fn *Fn) String() string {
+	if fn.Name == "" {
+		return "<fn>"
+	}
+
+	return fmt.Sprintf("<fn: %s>", fn.Name)
+}
+
+// Loop binds the names to values in a new scope and evaluates the body.
+// If the body evaluates to a recur, the body is evaluated again with names
+// bound to the new values.
+func Loop(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	if len(exprs) < 1 {
+		return nil, errors.New("at-least 1 argument required")
+	}
+
+	bindings, ok := exprs[0].(parens.Vector)
+	if !ok || len(bindings.Forms)%2 != 0 {
+		return nil, errors.New("first argument must be a vector of name-value pairs")
+	}
+
+	var names []string
+	var vals []interface{}
+	localScope := parens.NewScope(scope)
+	for i := 0; i < len(bindings.Forms); i += 2 {
+		sym, ok := bindings.Forms[i].(parens.Symbol)
+		if !ok {
+			return nil, fmt.Errorf("binding name must be a symbol, not '%s'", reflect.TypeOf(bindings.Forms[i]))
+		}
+
+		val, err := bindings.Forms[i+1].Eval(localScope)
+		if err != nil {
+			return nil, err
+		}
+
+		localScope.Bind(sym.Value, val)
+		names = append(names, sym.Value)
+		vals = append(vals, val)
+	}
+
+	body := markTailBody(exprs[1:], markRecur)
+	for {
+		val, err := Do(localScope, body)
+		if err != nil {
+			return nil, err
+		}
+
+		rc, ok := val.(*recurCall)
+		if !ok {
+			return val, nil
+		}
+
+		if len(rc.args) != len(names) {
+			return nil, fmt.Errorf("recur requires %d arguments, got %d", len(names), len(rc.args))
+		}
+
+		if err := parens.Step(parens.ContextOf(scope)); err != nil {
+			return nil, err
+		}
+
+		localScope = parens.NewScope(scope)
+		for i := range names {
+			localScope.Bind(names[i], rc.args[i])
+		}
+	}
+}
+
+// Recur is bound as recur. A recur in tail position of a function or loop
+// body signals the enclosing function or loop to re-evaluate with the new
+// values. Evaluating recur anywhere else is an error.
+func Recur(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	return nil, errors.New("recur must be in tail position")
+}
+
+type recurCall struct {
+	args []interface{}
+}
+
+type tailCall struct {
+	fn   *Fn
+	args []interface{}
+}
+
+// tailExpr wraps a list in tail position of a function or loop body. If
+// calls is true and the list is a call to another Fn, the evaluation returns
+// a tailCall to be executed by the enclosing Fn instead of invoking it. A
+// call to recur evaluates to a recurCall. Arguments of the macros in
+// tailForms are marked before evaluating the macro.
+type tailExpr struct {
+	parens.List
+	calls bool
+}
+
+func (te tailExpr) Eval(scope parens.Scope) (interface{}, error) {
+	sym, ok := te.Forms[0].(parens.Symbol)
+	if !ok {
+		return te.List.Eval(scope)
+	}
+
+	head, err := sym.Eval(scope)
+	if err != nil {
+		// member access forms and unbound names are handled by List.
+		return te.List.Eval(scope)
+	}
+
+	switch target := head.(type) {
+	case parens.MacroFunc:
+		ptr := funcPtr(target)
+		if ptr == funcPtr(Recur) {
+			return te.evalRecur(scope)
+		}
+
+		markArgs, found := tailForms[ptr]
+		if !found {
+			break
+		}
+
+		mark := markRecur
+		if te.calls {
+			mark = markTail
+		}
+
+		marked := te.List
+		marked.Forms = append([]parens.Expr{sym}, markArgs(te.Forms[1:], mark)...)
+		return marked.EvalWithHead(scope, head)
+
+	case *Fn:
+		if !te.calls {
+			break
+		}
+
+		args := []interface{}{}
+		for _, form := range te.Forms[1:] {
+			arg, err := form.Eval(scope)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+
+		return &tailCall{fn: target, args: args}, nil
+
+	case parens.Expander:
+		expanded, err := target.Expand(scope, te.Forms[1:])
+		if err != nil {
+			return nil, err
+		}
+		return markForm(expanded, te.calls).Eval(scope)
+	}
+
+	return te.List.EvalWithHead(scope, head)
+}
+
+// evalRecur returns a recurCall with the evaluated arguments of recur.
+func (te tailExpr) evalRecur(scope parens.Scope) (interface{}, error) {
+	args := []interface{}{}
+	for _, form := range te.Forms[1:] {
+		arg, err := form.Eval(scope)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	return &recurCall{args: args}, nil
+}
+
+// markTail wraps the form in tail position of a function body so that
+// calls made by it are executed as tail calls. Arguments of macros in
+// tailForms are marked recursively when the form is evaluated.
+func markTail(form parens.Expr) parens.Expr { return markForm(form, true) }
+
+// markRecur is same as markTail but handles only the recur forms. It is
+// used for the body of loop which must not return tail calls to its caller.
+func markRecur(form parens.Expr) parens.Expr { return markForm(form, false) }
+
+func markForm(form parens.Expr, calls bool) parens.Expr {
+	lst, ok := form.(parens.List)
+	if !ok || len(lst.Forms) == 0 {
+		return form
+	}
+
+	return tailExpr{List: lst, calls: calls}
+}
+
+// markTailBody marks the last form of the body.
+func markTailBody(forms []parens.Expr, mark marker) []parens.Expr {
+	if len(forms) == 0 {
+		return forms
+	}
+
+	res := append([]parens.Expr{}, forms...)
+	res[len(res)-1] = mark(res[len(res)-1])
+	return res
+}
+
+// markTailClauses marks the action of each (test action) clause.
+func markTailClauses(forms []parens.Expr, mark marker) []parens.Expr {
+	res := make([]parens.Expr, len(forms))
+	for i, form := range forms {
+		clause, ok := form.(parens.List)
+		if !ok || len(clause.Forms) != 2 {
+			res[i] = form
+			continue
+		}
+
+		clause.Forms = []parens.Expr{clause.Forms[0], mark(clause.Forms[1])}
+		res[i] = clause
+	}
+	return res
+}
+
+// markTailAfterTest marks the last form of the body following the test.
+func markTailAfterTest(forms []parens.Expr, mark marker) []parens.Expr {
+	if len(forms) < 2 {
+		return forms
+	}
+
+	return append([]parens.Expr{forms[0]}, markTailBody(forms[1:], mark)...)
+}
+
+// markTailBranches marks the then and else forms of if.
+func markTailBranches(forms []parens.Expr, mark marker) []parens.Expr {
+	res := append([]parens.Expr{}, forms...)
+	for i := 1; i < len(res) && i < 3; i++ {
+		res[i] = mark(res[i])
+	}
+	return res
+}
+
+// markTailCases marks the result forms and the default form of case.
+func markTailCases(forms []parens.Expr, mark marker) []parens.Expr {
+	res := append([]parens.Expr{}, forms...)
+	for i := 2; i < len(res); i += 2 {
+		res[i] = mark(res[i])
+	}
+
+	if len(res) > 1 && len(res)%2 == 0 {
+		res[len(res)-1] = mark(res[len(res)-1])
+	}
+	return res
+}
@@ -0,0 +1,270 @@
+package stdlib_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spy16/parens"
+	"github.com/spy16/parens/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFn_TailCall(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want interface{}
+	}{
+		{
+			name: "SelfRecursion",
+			src: `(defn count-down [n]
+                    (cond
+                      ((< n 1) "done")
+                      (true (count-down (- n 1)))))
+                  (count-down 200000)`,
+			want: "done",
+		},
+		{
+			name: "MutualRecursion",
+			src: `(defn ping [n] (cond ((< n 1) "ping") (true (pong (- n 1)))))
+                  (defn pong [n] (cond ((< n 1) "pong") (true (ping (- n 1)))))
+                  (ping 100001)`,
+			want: "pong",
+		},
+		{
+			name: "LoopRecur",
+			src: `(loop [i 0 acc 0]
+                    (cond
+                      ((> i 99999) acc)
+                      (true (recur (+ i 1) (+ acc i)))))`,
+			want: float64(4999950000),
+		},
+		{
+			name: "FnRecur",
+			src: `(defn fact [n acc]
+                    (cond
+                      ((< n 2) acc)
+                      (true (recur (- n 1) (* acc n)))))
+                  (fact 10 1)`,
+			want: float64(3628800),
+		},
+		{
+			name: "NonTailCall",
+			src: `(defn sum [n] (cond ((< n 1) 0) (true (+ n (sum (- n 1))))))
+                  (sum 100)`,
+			want: float64(5050),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.RegisterAll(scope))
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFn_ArityError(t *testing.T) {
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.RegisterAll(scope))
+
+	_, err := parens.ExecuteStr("(defn f [x] x) (f 1 2)", scope)
+	assert.Error(t, err)
+
+	_, err = parens.ExecuteStr("(loop [x 1] (recur 1 2))", scope)
+	assert.Error(t, err)
+}
+
+func TestFn_RecurNotInTail(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{name: "TopLevel", src: `(recur 1)`},
+		{name: "LoopNotLast", src: `(loop [x 0] (do (recur 1) 2))`},
+		{name: "LoopArgument", src: `(loop [x 0] (+ 1 (recur 2)))`},
+		{name: "FnArgument", src: `(defn f [x] (+ 1 (recur 2))) (f 1)`},
+		{name: "IfTest", src: `(loop [x 0] (if (recur 1) 1 2))`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.RegisterAll(scope))
+
+			_, err := parens.ExecuteStr(tt.src, scope)
+			require.Error(t, err)
+			assert.True(t, strings.HasSuffix(err.Error(), "recur must be in tail position"), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestFn_ReboundSpecialForms(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want interface{}
+	}{
+		{
+			name: "ReboundAnd",
+			src: `(label and (lambda [a b] [a b]))
+                  (defn inc [x] (+ x 1))
+                  (defn f [x] (and x (inc x)))
+                  (f 1)`,
+			want: []interface{}{int64(1), float64(2)},
+		},
+		{
+			name: "ReboundIf",
+			src: `(label if (lambda [& args] (vec args)))
+                  (defn g [x] "g")
+                  (defn f [x] (if x (g x) 0))
+                  (f true)`,
+			want: []interface{}{true, "g", int64(0)},
+		},
+		{
+			name: "ReboundRecur",
+			src: `(label recur (lambda [x] (* x 10)))
+                  (defn f [x] (recur x))
+                  [(f 2) (loop [x 3] (recur x))]`,
+			want: []interface{}{float64(20), float64(30)},
+		},
+		{
+			name: "AliasedIf",
+			src: `(label when-else if)
+                  (defn count-down [n] (when-else (< n 1) "done" (count-down (- n 1))))
+                  (count-down 100000)`,
+			want: "done",
+		},
+		{
+			name: "ShadowedInLet",
+			src: `(defn inc [x] (+ x 1))
+                  (defn f [x] (let [do (lambda [a] [a])] (do (inc x))))
+                  (f 1)`,
+			want: []interface{}{float64(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.RegisterAll(scope))
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, parens.ToGo(got))
+		})
+	}
+}
+
+func TestFn_TailCallEvaluatesHeadOnce(t *testing.T) {
+	t.Parallel()
+
+	base := parens.NewScope(nil)
+	require.NoError(t, stdlib.RegisterAll(base))
+	base.Bind("concat", func(a, b string) string { return a + b })
+
+	scope := &countingScope{Scope: base, name: "concat"}
+	_, err := parens.ExecuteStr(`(defn f [] (concat "a" "b"))`, scope)
+	require.NoError(t, err)
+
+	got, err := parens.ExecuteStr(`(f)`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, "ab", got)
+	assert.Equal(t, 1, scope.gets)
+}
+
+// countingScope counts the lookups of name.
+type countingScope struct {
+	parens.Scope
+	name string
+	gets int
+}
+
+func (cs *countingScope) Get(name string) (interface{}, error) {
+	if name == cs.name {
+		cs.gets++
+	}
+	return cs.Scope.Get(name)
+}
+
+func TestFn_AsGoFunc(t *testing.T) {
+	t.Parallel()
+
+	type event struct{ Name string }
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+
+	nums := []int{5, 2, 8, 1}
+	scope.Bind("nums", nums)
+	scope.Bind("at", func(i int) int { return nums[i] })
+	scope.Bind("sort-slice", sort.Slice)
+
+	_, err := parens.ExecuteStr(`(sort-slice nums (lambda [i j] (< (at i) (at j))))`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 5, 8}, nums)
+
+	var handled []string
+	scope.Bind("on-event", func(name string, handler func(event) error) error {
+		return handler(event{Name: name})
+	})
+	scope.Bind("handled", func(name string) { handled = append(handled, name) })
+
+	got, err := parens.ExecuteStr(`(on-event "start" (lambda [ev] (handled (.-Name ev))))`, scope)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	assert.Equal(t, []string{"start"}, handled)
+
+	_, err = parens.ExecuteStr(`(on-event "stop" (lambda [ev] (undefined ev)))`, scope)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "name 'undefined' not found")
+}
+
+func TestFn_StoredGoFunc(t *testing.T) {
+	t.Parallel()
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+
+	var stored func(int64) (int64, error)
+	scope.Bind("store", func(fn func(int64) (int64, error)) { stored = fn })
+	scope.Bind("call-cancelled", func(ctx context.Context, cancel func(), fn func() error) error {
+		cancel()
+		return fn()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := parens.ExecuteWithOptions(strings.NewReader(`
+		(store (lambda [n] (loop [i 0] (if (< i n) (recur (+ i 1)) (* 2 i)))))`),
+		scope, parens.Options{Context: ctx, Limits: parens.Limits{MaxSteps: 100}})
+	require.NoError(t, err)
+	cancel()
+
+	got, err := stored(200)
+	require.NoError(t, err, "stored callback must not use the context of the finished evaluation")
+	assert.Equal(t, int64(400), got)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	scope.Bind("cancel", cancel)
+
+	_, err = parens.ExecuteStrContext(ctx, `(call-cancelled cancel (lambda [] (+ 1 2)))`, scope)
+	assert.True(t, errors.Is(err, context.Canceled), "callback must use the context while the call is running: %v", err)
+}
@@ -0,0 +1,48 @@
+package stdlib
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/spy16/parens"
+)
+
+// FileSystem is used by the file related functions such as load to access
+// files. Hosts can provide a restricted or virtual implementation.
+type FileSystem interface {
+	Open(name string) (io.ReadCloser, error)
+}
+
+// OSFileSystem is a FileSystem backed by the files of the host operating
+// system.
+type OSFileSystem struct{}
+
+// Open opens the named file for reading using os.Open.
+func (OSFileSystem) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+// LoadFile returns function that reads and executes a lisp file.
+func LoadFile(env parens.Scope) func(ctx context.Context, file string) interface{} {
+	return LoadFileFrom(OSFileSystem{}, env)
+}
+
+// LoadFileFrom returns function that reads and executes a lisp file from
+// the given file system.
+func LoadFileFrom(fs FileSystem, env parens.Scope) func(ctx context.Context, file string) interface{} {
+	return func(ctx context.Context, file string) interface{} {
+		fh, err := fs.Open(file)
+		if err != nil {
+			panic(err)
+		}
+		defer fh.Close()
+
+		val, err := parens.ExecuteContext(ctx, fh, env)
+		if err != nil {
+			panic(err)
+		}
+
+		return val
+	}
+}
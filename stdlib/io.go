@@ -3,43 +3,83 @@ package stdlib
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
+
+	"github.com/k0kubun/pp"
+	"github.com/spy16/parens"
 )
 
-var io = []mapEntry{
-	entry("println", println,
-		"Concatenates arguments and prints with a newline at the end",
-	),
-	entry("print", print,
-		"Concatenates arguments and prints without a newline at the end",
-	),
-	entry("printf", printf,
-		"Formats the first string using remaining arguments and prints",
-	),
-	entry("read", read,
-		"Reads a line from the console. Throws error if fails",
-		"Usage: (read)",
-	),
-}
+func ioEntries(in io.Reader, out io.Writer) []mapEntry {
+	// reader and writer are shared by all the scopes the entries are bound
+	// to and hence must be guarded against concurrent evaluations.
+	var inMu sync.Mutex
+	rd := bufio.NewReader(in)
+	out = &lockedWriter{w: out}
 
-func println(args ...interface{}) {
-	fmt.Println(args...)
+	return []mapEntry{
+		entry("println", func(args ...interface{}) {
+			fmt.Fprintln(out, args...)
+		},
+			"Concatenates arguments and prints with a newline at the end",
+		),
+		entry("print", func(args ...interface{}) {
+			fmt.Fprint(out, args...)
+		},
+			"Concatenates arguments and prints without a newline at the end",
+		),
+		entry("printf", func(msg string, args ...interface{}) {
+			fmt.Fprintf(out, msg, args...)
+		},
+			"Formats the first string using remaining arguments and prints",
+		),
+		entry("read", func() string {
+			inMu.Lock()
+			defer inMu.Unlock()
+			return readLine(rd)
+		},
+			"Reads a line from the console. Throws error if fails",
+			"Usage: (read)",
+		),
+		entry("inspect", InspectTo(out),
+			"Usage: (inspect expr)",
+		),
+	}
 }
 
-func printf(msg string, args ...interface{}) {
-	fmt.Printf(msg, args...)
+// Inspect dumps the exprs in a formatted manner.
+func Inspect(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	return InspectTo(os.Stdout)(scope, exprs)
 }
 
-func print(args ...interface{}) {
-	fmt.Print(args...)
+// InspectTo returns the inspect macro which dumps the exprs in a formatted
+// manner to the given writer.
+func InspectTo(w io.Writer) parens.MacroFunc {
+	return func(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+		pp.Fprintln(w, exprs)
+		return nil, nil
+	}
 }
 
-func read() string {
-	reader := bufio.NewReader(os.Stdin)
-	text, err := reader.ReadString('\n')
-	if err != nil {
+func readLine(rd *bufio.Reader) string {
+	text, err := rd.ReadString('\n')
+	if err != nil && (err != io.EOF || text == "") {
 		panic(err)
 	}
 
-	return text[0 : len(text)-1] // ignore the '\n' char
+	return strings.TrimSuffix(text, "\n") // ignore the '\n' char
+}
+
+// lockedWriter serializes the writes to w.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
 }
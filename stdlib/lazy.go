@@ -0,0 +1,392 @@
+package stdlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spy16/parens"
+)
+
+var lazy = []mapEntry{
+	entry("lazy-seq", parens.MacroFunc(Lazy),
+		"Returns a lazy sequence which evaluates the body only when the items",
+		"are needed. Body must return nil, a collection or a sequence.",
+		"Usage: (lazy-seq body...)",
+	),
+	entry("iterate", Iterate,
+		"Returns an infinite lazy sequence of x, (f x), (f (f x)) etc.",
+		"Usage: (iterate f x)",
+	),
+	entry("repeat", Repeat,
+		"Returns a lazy sequence of x repeated n times (infinitely if n is",
+		"not given)",
+		"Usage: (repeat x) or (repeat n x)",
+	),
+	entry("cycle", Cycle,
+		"Returns an infinite lazy sequence repeating the items of coll",
+		"Usage: (cycle coll)",
+	),
+	entry("vec", Vec,
+		"Realizes the collection or sequence into a vector",
+		"Usage: (vec coll)",
+	),
+}
+
+// maxPrintItems is the number of items of a lazy sequence realized for
+// printing it.
+const maxPrintItems = 32
+
+// Iterator produces items of a sequence on demand. Implement Iterator to
+// expose channels, cursors, generators etc. as Lisp sequences. Iterators
+// passed to sequence functions are wrapped using NewLazySeq.
+type Iterator interface {
+	// Next returns the next item. ok must be false when there are no more
+	// items.
+	Next(ctx context.Context) (item interface{}, ok bool, err error)
+}
+
+// IteratorFunc implements Iterator using a Go function.
+type IteratorFunc func(ctx context.Context) (interface{}, bool, error)
+
+// Next calls the function.
+func (f IteratorFunc) Next(ctx context.Context) (interface{}, bool, error) { return f(ctx) }
+
+// LazySeq is a sequence whose items are computed only when they are needed.
+// Realized items are cached and hence a LazySeq can be consumed multiple
+// times. LazySeq is safe for concurrent use and every item is computed
+// only once even if it is requested by multiple goroutines.
+type LazySeq struct {
+	// lock is held while realizing the sequence. It is nil if the sequence
+	// was created realized.
+	lock     chan struct{}
+	thunk    func(ctx context.Context) (interface{}, error)
+	realized bool
+	empty    bool
+	first    interface{}
+	rest     interface{}
+}
+
+// realizingKey marks the context used for realizing a sequence so that the
+// sequence depending on itself can be detected.
+type realizingKey struct{ ls *LazySeq }
+
+// NewLazySeq returns a lazy sequence of the items produced by the iterator.
+// Each item is requested from the iterator only once.
+func NewLazySeq(it Iterator) *LazySeq {
+	return lazySeq(func(ctx context.Context) (interface{}, error) {
+		item, ok, err := it.Next(ctx)
+		if err != nil || !ok {
+			return nil, err
+		}
+
+		return lazyCons(item, NewLazySeq(it)), nil
+	})
+}
+
+// Realize realizes all the items of the sequence. Realizing an infinite
+// sequence continues until ctx is cancelled or the evaluation limits set
+// in ctx are exceeded.
+func (ls *LazySeq) Realize(ctx context.Context) ([]interface{}, error) {
+	items := []interface{}{}
+
+	var s interface{} = ls
+	for {
+		first, rest, ok, err := uncons(ctx, s)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return items, nil
+		}
+
+		items = append(items, first)
+		s = rest
+	}
+}
+
+// String realizes and formats at-most first 32 items of the sequence.
+func (ls *LazySeq) String() string {
+	var parts []string
+
+	var s interface{} = ls
+	for {
+		first, rest, ok, err := uncons(context.Background(), s)
+		if err != nil {
+			parts = append(parts, fmt.Sprintf("<error: %v>", err))
+			break
+		} else if !ok {
+			break
+		} else if len(parts) == maxPrintItems {
+			parts = append(parts, "...")
+			break
+		}
+
+		parts = append(parts, fmt.Sprintf("%v", first))
+		s = rest
+	}
+
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func (ls *LazySeq) uncons(ctx context.Context) (interface{}, interface{}, bool, error) {
+	if ls.lock == nil {
+		return ls.first, ls.rest, !ls.empty, nil
+	}
+
+	if ctx.Value(realizingKey{ls}) != nil {
+		return nil, nil, false, errors.New("lazy sequence depends on itself")
+	}
+
+	select {
+	case ls.lock <- struct{}{}:
+		defer func() { <-ls.lock }()
+
+	case <-ctx.Done():
+		return nil, nil, false, ctx.Err()
+	}
+
+	if !ls.realized {
+		if err := parens.Step(ctx); err != nil {
+			return nil, nil, false, err
+		}
+
+		rctx := context.WithValue(ctx, realizingKey{ls}, true)
+		val, err := ls.thunk(rctx)
+		if err == nil {
+			val, err = seqOf(val)
+		}
+
+		var first, rest interface{}
+		var ok bool
+		if err == nil {
+			first, rest, ok, err = uncons(rctx, val)
+		}
+
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		ls.first, ls.rest, ls.empty = first, rest, !ok
+		ls.realized, ls.thunk = true, nil
+	}
+
+	return ls.first, ls.rest, !ls.empty, nil
+}
+
+// Lazy returns a lazy sequence which evaluates the body when the items are
+// needed. The body is evaluated using the context of the realization.
+func Lazy(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	return lazySeq(func(ctx context.Context) (interface{}, error) {
+		return Do(parens.WithContext(ctx, scope), exprs)
+	}), nil
+}
+
+// Iterate returns an infinite lazy sequence of x, (f x), (f (f x)) etc.
+func Iterate(f interface{}, x interface{}) *LazySeq {
+	return lazyCons(x, lazySeq(func(ctx context.Context) (interface{}, error) {
+		next, err := parens.Call(ctx, f, x)
+		if err != nil {
+			return nil, err
+		}
+
+		return Iterate(f, next), nil
+	}))
+}
+
+// Repeat returns a lazy sequence repeating x. Args can be (x) for an
+// infinite sequence or (n x) for n items.
+func Repeat(args ...interface{}) (*LazySeq, error) {
+	switch len(args) {
+	case 1:
+		ls := lazyCons(args[0], nil)
+		ls.rest = ls
+		return ls, nil
+
+	case 2:
+		n, ok := args[0].(int64)
+		if !ok {
+			return nil, fmt.Errorf("count must be an integer, not '%s'", reflect.TypeOf(args[0]))
+		}
+
+		infinite, _ := Repeat(args[1])
+		return takeSeq(n, infinite), nil
+
+	default:
+		return nil, fmt.Errorf("1 or 2 arguments required, got %d", len(args))
+	}
+}
+
+// Cycle returns an infinite lazy sequence repeating the items of coll. The
+// result is empty if the collection is empty.
+func Cycle(coll interface{}) (*LazySeq, error) {
+	s, err := seqOf(coll)
+	if err != nil {
+		return nil, err
+	}
+
+	return cycleSeq(s, s), nil
+}
+
+// Vec realizes the collection into a vector.
+func Vec(ctx context.Context, coll interface{}) (*parens.PersistentVector, error) {
+	if vec, ok := coll.(*parens.PersistentVector); ok {
+		return vec, nil
+	}
+
+	items, err := seqItems(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	return allocatedVector(ctx, parens.NewVector(items...))
+}
+
+func cycleSeq(s, cur interface{}) *LazySeq {
+	return lazySeq(func(ctx context.Context) (interface{}, error) {
+		first, rest, ok, err := uncons(ctx, cur)
+		if err == nil && !ok {
+			first, rest, ok, err = uncons(ctx, s)
+		}
+
+		if err != nil || !ok {
+			return nil, err
+		}
+		return lazyCons(first, cycleSeq(s, rest)), nil
+	})
+}
+
+func takeSeq(n int64, s interface{}) *LazySeq {
+	return lazySeq(func(ctx context.Context) (interface{}, error) {
+		if n <= 0 {
+			return nil, nil
+		}
+
+		first, rest, ok, err := uncons(ctx, s)
+		if err != nil || !ok {
+			return nil, err
+		}
+		return lazyCons(first, takeSeq(n-1, rest)), nil
+	})
+}
+
+func dropSeq(n int64, s interface{}) *LazySeq {
+	return lazySeq(func(ctx context.Context) (interface{}, error) {
+		for ; n > 0; n-- {
+			_, rest, ok, err := uncons(ctx, s)
+			if err != nil || !ok {
+				return nil, err
+			}
+			s = rest
+		}
+
+		return s, nil
+	})
+}
+
+func mapSeq(f interface{}, seqs []interface{}) *LazySeq {
+	return lazySeq(func(ctx context.Context) (interface{}, error) {
+		args := make([]interface{}, len(seqs))
+		rests := make([]interface{}, len(seqs))
+		for i, s := range seqs {
+			first, rest, ok, err := uncons(ctx, s)
+			if err != nil || !ok {
+				return nil, err
+			}
+			args[i], rests[i] = first, rest
+		}
+
+		val, err := parens.Call(ctx, f, args...)
+		if err != nil {
+			return nil, err
+		}
+		return lazyCons(val, mapSeq(f, rests)), nil
+	})
+}
+
+func filterSeq(pred interface{}, s interface{}) *LazySeq {
+	return lazySeq(func(ctx context.Context) (interface{}, error) {
+		for {
+			first, rest, ok, err := uncons(ctx, s)
+			if err != nil || !ok {
+				return nil, err
+			}
+
+			keep, err := parens.Call(ctx, pred, first)
+			if err != nil {
+				return nil, err
+			} else if parens.IsTruthy(keep) {
+				return lazyCons(first, filterSeq(pred, rest)), nil
+			}
+
+			if err := parens.Step(ctx); err != nil {
+				return nil, err
+			}
+			s = rest
+		}
+	})
+}
+
+func concatSeq(seqs []interface{}) *LazySeq {
+	return lazySeq(func(ctx context.Context) (interface{}, error) {
+		for len(seqs) > 0 {
+			first, rest, ok, err := uncons(ctx, seqs[0])
+			if err != nil {
+				return nil, err
+			} else if ok {
+				next := append([]interface{}{rest}, seqs[1:]...)
+				return lazyCons(first, concatSeq(next)), nil
+			}
+			seqs = seqs[1:]
+		}
+
+		return nil, nil
+	})
+}
+
+func rangeSeq(start, step float64, end *float64, ints bool) *LazySeq {
+	var from func(i int64) *LazySeq
+	from = func(i int64) *LazySeq {
+		return lazySeq(func(ctx context.Context) (interface{}, error) {
+			v := start + float64(i)*step
+			if end != nil && ((step > 0 && v >= *end) || (step < 0 && v <= *end)) {
+				return nil, nil
+			}
+
+			if ints {
+				return lazyCons(int64(v), from(i+1)), nil
+			}
+			return lazyCons(v, from(i+1)), nil
+		})
+	}
+
+	return from(0)
+}
+
+func lazySeq(thunk func(ctx context.Context) (interface{}, error)) *LazySeq {
+	return &LazySeq{thunk: thunk, lock: make(chan struct{}, 1)}
+}
+
+func lazyCons(first, rest interface{}) *LazySeq {
+	return &LazySeq{realized: true, first: first, rest: rest}
+}
+
+// chanIterator reads the items of a Go channel until it is closed.
+type chanIterator struct {
+	ch reflect.Value
+}
+
+func (ci chanIterator) Next(ctx context.Context) (interface{}, bool, error) {
+	chosen, item, ok := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ci.ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+	})
+	if chosen == 1 {
+		return nil, false, ctx.Err()
+	} else if !ok {
+		return nil, false, nil
+	}
+
+	return item.Interface(), true, nil
+}
@@ -0,0 +1,105 @@
+package stdlib_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spy16/parens"
+	"github.com/spy16/parens/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits_TailCalls(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{name: "DefnRecursion", src: `(defn f [] (f)) (f)`},
+		{name: "MutualRecursion", src: `(defn ping [] (pong)) (defn pong [] (ping)) (ping)`},
+		{name: "LoopRecur", src: `(loop [x 0] (recur (+ x 1)))`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("MaxSteps", func(t *testing.T) {
+				scope := parens.NewScope(nil)
+				require.NoError(t, stdlib.RegisterAll(scope))
+
+				_, err := parens.ExecuteWithOptions(strings.NewReader(tt.src), scope, parens.Options{
+					Limits: parens.Limits{MaxSteps: 1000, MaxDepth: 100},
+				})
+				assert.True(t, errors.Is(err, parens.ErrStepLimit), "unexpected error: %v", err)
+			})
+
+			t.Run("Deadline", func(t *testing.T) {
+				scope := parens.NewScope(nil)
+				require.NoError(t, stdlib.RegisterAll(scope))
+
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+
+				_, err := parens.ExecuteStrContext(ctx, tt.src, scope)
+				assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+			})
+		})
+	}
+}
+
+func TestLimits_Alloc(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		wantErr bool
+	}{
+		{
+			name: "LoopReturningExisting",
+			src: `(label big (vec (range 1000)))
+				(loop [i 0 res nil]
+					(if (< i 100)
+						(recur (+ i 1) (do (get {:v big} :v)))
+						res))`,
+		},
+		{
+			name: "SameStringRepeatedly",
+			src:  `(label s "0123456789") (loop [i 0] (if (< i 600) (recur (+ i 1)) s))`,
+		},
+		{
+			name:    "LoopBuildingCollections",
+			src:     `(loop [i 0 acc []] (if (< i 100) (recur (+ i 1) (conj acc i)) acc))`,
+			wantErr: true,
+		},
+		{
+			name:    "NewInstances",
+			src:     `(loop [i 0] (when (< i 2000) (new Pair) (recur (+ i 1))))`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.RegisterAll(scope))
+			scope.Bind("Pair", reflect.TypeOf(struct{ A, B int }{}))
+
+			_, err := parens.ExecuteWithOptions(strings.NewReader(tt.src), scope, parens.Options{
+				Limits: parens.Limits{MaxAlloc: 2000},
+			})
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, parens.ErrAllocLimit), "unexpected error: %v", err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,411 @@
+package stdlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/spy16/parens"
+)
+
+var macros = []mapEntry{
+	entry("syntax-quote", parens.MacroFunc(SyntaxQuote),
+		"Returns the template form with all unquoted forms replaced by their",
+		"values. Symbols ending with '#' are replaced with unique symbols.",
+		"Usage: `form or (syntax-quote form)",
+	),
+	entry("unquote", parens.MacroFunc(unquoteOutsideSyntaxQuote("unquote")),
+		"Evaluates the form inside a syntax-quote template.",
+		"Usage: ~form or (unquote form)",
+	),
+	entry("unquote-splicing", parens.MacroFunc(unquoteOutsideSyntaxQuote("unquote-splicing")),
+		"Evaluates the form inside a syntax-quote template and splices the",
+		"resulting sequence into the enclosing form.",
+		"Usage: ~@form or (unquote-splicing form)",
+	),
+	entry("defmacro", parens.MacroFunc(Defmacro),
+		"Defines a named macro. Macro receives the arguments un-evaluated",
+		"and the form returned by the body is evaluated in place of the call.",
+		"Usage: (defmacro <name> [params] body)",
+	),
+	entry("macroexpand-1", parens.MacroFunc(MacroExpand1),
+		"Expands the form once if it is a macro call.",
+		"Usage: (macroexpand-1 '(macro args...))",
+	),
+	entry("macroexpand", parens.MacroFunc(MacroExpand),
+		"Repeatedly expands the form until it is no longer a macro call.",
+		"Usage: (macroexpand '(macro args...))",
+	),
+}
+
+var gensymCounter int64
+
+// Macro represents a macro defined in lisp using defmacro. Macro implements
+// parens.Expander.
+type Macro struct {
+	Name   string
+	params params
+	body   []parens.Expr
+	scope  parens.Scope
+}
+
+// Expand binds the un-evaluated forms to the params and returns the form
+// produced by evaluating the macro body. The body is evaluated using the
+// context of the scope of the macro call.
+func (m *Macro) Expand(scope parens.Scope, forms []parens.Expr) (parens.Expr, error) {
+	args := make([]interface{}, len(forms))
+	for i, form := range forms {
+		args[i] = form
+	}
+
+	localScope := parens.WithContext(parens.ContextOf(scope), parens.NewScope(m.scope))
+	if err := m.params.bind(localScope, args); err != nil {
+		return nil, fmt.Errorf("macro '%s': %w", m.Name, err)
+	}
+
+	val, err := Do(localScope, m.body)
+	if err != nil {
+		return nil, err
+	}
+
+	return toExpr(val), nil
+}
+
+func (m *Macro) String() string { return fmt.Sprintf("<macro: %s>", m.Name) }
+
+// Defmacro defines a lisp macro and binds it with the given name into the
+// scope. Params may end with '& rest' to collect the remaining forms and
+// can be destructuring patterns as in lambda.
+func Defmacro(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	if len(exprs) < 3 {
+		return nil, fmt.Errorf("3 or more arguments required, got %d", len(exprs))
+	}
+
+	sym, ok := exprs[0].(parens.Symbol)
+	if !ok {
+		return nil, fmt.Errorf("first argument must be symbol, not '%s'", reflect.TypeOf(exprs[0]))
+	}
+
+	paramList, ok := exprs[1].(parens.Vector)
+	if !ok {
+		return nil, fmt.Errorf("second argument must be vector of symbols, not '%s'", reflect.TypeOf(exprs[1]))
+	}
+
+	params, err := parseParams(paramList)
+	if err != nil {
+		return nil, err
+	}
+
+	macro := &Macro{
+		Name:   sym.Value,
+		params: params,
+		body:   exprs[2:],
+		scope:  scope,
+	}
+
+	scope.Bind(sym.Value, macro)
+	return sym.Value, nil
+}
+
+// MacroExpand1 evaluates the argument to obtain a form and expands it once
+// if it is a call to a macro implementing parens.Expander.
+func MacroExpand1(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	form, err := macroexpandArg(scope, exprs)
+	if err != nil {
+		return nil, err
+	}
+
+	expanded, _, err := expandOnce(scope, form)
+	return expanded, err
+}
+
+// MacroExpand is same as MacroExpand1 but repeats the expansion until the
+// form is no longer a macro call.
+func MacroExpand(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	form, err := macroexpandArg(scope, exprs)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		expanded, ok, err := expandOnce(scope, form)
+		if err != nil || !ok {
+			return expanded, err
+		}
+		form = expanded
+	}
+}
+
+func macroexpandArg(scope parens.Scope, exprs []parens.Expr) (parens.Expr, error) {
+	if len(exprs) != 1 {
+		return nil, fmt.Errorf("exactly 1 argument required, got %d", len(exprs))
+	}
+
+	val, err := exprs[0].Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return toExpr(val), nil
+}
+
+func expandOnce(scope parens.Scope, form parens.Expr) (parens.Expr, bool, error) {
+	lst, ok := form.(parens.List)
+	if !ok || len(lst.Forms) == 0 {
+		return form, false, nil
+	}
+
+	sym, ok := lst.Forms[0].(parens.Symbol)
+	if !ok {
+		return form, false, nil
+	}
+
+	val, err := scope.Get(sym.Value)
+	if err != nil {
+		return form, false, nil
+	}
+
+	expander, ok := val.(parens.Expander)
+	if !ok {
+		return form, false, nil
+	}
+
+	expanded, err := expander.Expand(scope, lst.Forms[1:])
+	if err != nil {
+		return nil, false, err
+	}
+
+	return expanded, true, nil
+}
+
+// SyntaxQuote returns the template with (unquote form) replaced by value of
+// the form and (unquote-splicing form) replaced by the items of the value
+// of the form. Symbols ending with '#' are replaced by generated symbols
+// which are unique to each expansion.
+func SyntaxQuote(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	if len(exprs) != 1 {
+		return nil, fmt.Errorf("exactly 1 argument required, got %d", len(exprs))
+	}
+
+	sq := syntaxQuoter{
+		scope:   scope,
+		gensyms: map[string]string{},
+	}
+	return sq.expand(exprs[0])
+}
+
+type syntaxQuoter struct {
+	scope   parens.Scope
+	gensyms map[string]string
+}
+
+func (sq syntaxQuoter) expand(form parens.Expr) (parens.Expr, error) {
+	switch f := form.(type) {
+	case parens.Symbol:
+		if len(f.Value) > 1 && strings.HasSuffix(f.Value, "#") {
+			name, found := sq.gensyms[f.Value]
+			if !found {
+				id := atomic.AddInt64(&gensymCounter, 1)
+				name = fmt.Sprintf("%s__%d__auto__", strings.TrimSuffix(f.Value, "#"), id)
+				sq.gensyms[f.Value] = name
+			}
+			f.Value = name
+		}
+		return f, nil
+
+	case parens.List:
+		if arg, ok := unquoteArg(f, "unquote"); ok {
+			val, err := arg.Eval(sq.scope)
+			if err != nil {
+				return nil, err
+			}
+			return toExpr(val), nil
+		}
+
+		if _, ok := unquoteArg(f, "unquote-splicing"); ok {
+			return nil, errors.New("unquote-splicing (~@) used outside of a list")
+		}
+
+		forms, err := sq.expandAll(f.Forms)
+		if err != nil {
+			return nil, err
+		}
+		f.Forms = forms
+		return f, nil
+
+	case parens.Vector:
+		forms, err := sq.expandAll(f.Forms)
+		if err != nil {
+			return nil, err
+		}
+		f.Forms = forms
+		return f, nil
+
+	case parens.Set:
+		forms, err := sq.expandAll(f.Forms)
+		if err != nil {
+			return nil, err
+		}
+		f.Forms = forms
+		return f, nil
+
+	case parens.HashMap:
+		// keys and values are expanded together so that the forms spliced
+		// in are paired up in order as in the literal.
+		kvs := make([]parens.Expr, 0, 2*len(f.Keys))
+		for i := range f.Keys {
+			kvs = append(kvs, f.Keys[i], f.Values[i])
+		}
+
+		forms, err := sq.expandAll(kvs)
+		if err != nil {
+			return nil, err
+		} else if len(forms)%2 != 0 {
+			return nil, fmt.Errorf("map literal must contain an even number of forms, got %d", len(forms))
+		}
+
+		f.Keys = make([]parens.Expr, 0, len(forms)/2)
+		f.Values = make([]parens.Expr, 0, len(forms)/2)
+		for i := 0; i < len(forms); i += 2 {
+			f.Keys = append(f.Keys, forms[i])
+			f.Values = append(f.Values, forms[i+1])
+		}
+		return f, nil
+	}
+
+	return form, nil
+}
+
+func (sq syntaxQuoter) expandAll(forms []parens.Expr) ([]parens.Expr, error) {
+	res := []parens.Expr{}
+	for _, form := range forms {
+		if lst, ok := form.(parens.List); ok {
+			if arg, ok := unquoteArg(lst, "unquote-splicing"); ok {
+				val, err := arg.Eval(sq.scope)
+				if err != nil {
+					return nil, err
+				}
+
+				items, err := spliceItems(parens.ContextOf(sq.scope), val)
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, items...)
+				continue
+			}
+		}
+
+		expanded, err := sq.expand(form)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, expanded)
+	}
+
+	return res, nil
+}
+
+func unquoteArg(lst parens.List, name string) (parens.Expr, bool) {
+	if len(lst.Forms) != 2 {
+		return nil, false
+	}
+
+	sym, ok := lst.Forms[0].(parens.Symbol)
+	if !ok || sym.Value != name {
+		return nil, false
+	}
+
+	return lst.Forms[1], true
+}
+
+func spliceItems(ctx context.Context, val interface{}) ([]parens.Expr, error) {
+	switch v := val.(type) {
+	case nil:
+		return nil, nil
+
+	case parens.List:
+		return v.Forms, nil
+
+	case parens.Vector:
+		return v.Forms, nil
+
+	case *parens.PersistentVector:
+		val = v.ToSlice()
+
+	case *LazySeq:
+		items, err := v.Realize(ctx)
+		if err != nil {
+			return nil, err
+		}
+		val = items
+	}
+
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("cannot splice value of type '%s'", rv.Type())
+	}
+
+	items := make([]parens.Expr, rv.Len())
+	for i := range items {
+		items[i] = toExpr(rv.Index(i).Interface())
+	}
+	return items, nil
+}
+
+func unquoteOutsideSyntaxQuote(name string) parens.MacroFunc {
+	return func(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+		return nil, fmt.Errorf("%s used outside of syntax-quote", name)
+	}
+}
+
+// toExpr converts a value into a form which evaluates to the same value.
+// Forms are returned as is.
+func toExpr(v interface{}) parens.Expr {
+	switch val := v.(type) {
+	case parens.Expr:
+		return val
+
+	case int64:
+		return parens.Int64(val)
+
+	case int:
+		return parens.Int64(val)
+
+	case float64:
+		return parens.Float64(val)
+
+	case string:
+		return parens.String(val)
+
+	case []interface{}:
+		vec := parens.Vector{}
+		for _, item := range val {
+			vec.Forms = append(vec.Forms, toExpr(item))
+		}
+		return vec
+
+	case *parens.PersistentVector:
+		return toExpr(val.ToSlice())
+
+	case *parens.PersistentMap:
+		hm := parens.HashMap{}
+		val.Range(func(key, v interface{}) bool {
+			hm.Keys = append(hm.Keys, toExpr(key))
+			hm.Values = append(hm.Values, toExpr(v))
+			return true
+		})
+		return hm
+
+	case *parens.PersistentSet:
+		set := parens.Set{}
+		for _, item := range val.ToSlice() {
+			set.Forms = append(set.Forms, toExpr(item))
+		}
+		return set
+	}
+
+	return anyExpr{val: v}
+}
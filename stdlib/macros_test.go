@@ -0,0 +1,149 @@
+package stdlib_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/spy16/parens"
+	"github.com/spy16/parens/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacro_CallerContext(t *testing.T) {
+	t.Parallel()
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := parens.ExecuteStrContext(ctx, "(defmacro twice [x] `(* 2 ~x))", scope)
+	require.NoError(t, err)
+	cancel()
+
+	got, err := parens.ExecuteStrContext(context.Background(), "(twice 21)", scope)
+	require.NoError(t, err)
+	assert.Equal(t, float64(42), got)
+
+	_, err = parens.ExecuteStrContext(ctx, "(twice 21)", scope)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestSyntaxQuote(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr string
+	}{
+		{name: "Symbols", src: "`(a b)", want: "(a b)"},
+		{name: "Unquote", src: "(label x 5) `(a ~x)", want: "(a 5)"},
+		{name: "UnquoteForm", src: "`(a ~(count [1 2 3]))", want: "(a 3)"},
+		{name: "NestedQuote", src: "(label x 5) `(a '(b ~x))", want: "(a (quote (b 5)))"},
+		{name: "SpliceList", src: "(label xs [1 2 3]) `(a ~@xs b)", want: "(a 1 2 3 b)"},
+		{name: "SpliceNil", src: "`(a ~@nil)", want: "(a)"},
+		{name: "SpliceLazy", src: "`(a ~@(range 3))", want: "(a 0 1 2)"},
+		{name: "SpliceVector", src: "(label xs [1 2 3]) `[a ~@xs]", want: "[a 1 2 3]"},
+		{name: "SpliceSet", src: "(label xs [1 2 3]) `#{~@xs}", want: "#{1 2 3}"},
+		{name: "SpliceMap", src: "(label xs [1 2 3]) `{~@xs 4}", want: "{1 2 3 4}"},
+		{name: "SpliceMapEvaluated", src: "(label xs [:a 1 :b]) (get (eval `{~@xs 2}) :b)", want: "2"},
+		{name: "SpliceMapOdd", src: "(label xs [1 2 3]) `{~@xs 4 ~@[5] ~@[]}", wantErr: "map literal must contain an even number of forms, got 5"},
+		{name: "SpliceNotSeq", src: "`(a ~@1)", wantErr: "cannot splice value of type 'int64'"},
+		{name: "SpliceOutsideList", src: "(label xs [1 2 3]) `~@xs", wantErr: "unquote-splicing (~@) used outside of a list"},
+		{name: "UnquoteOutside", src: "(label x 5) ~x", wantErr: "unquote used outside of syntax-quote"},
+		{name: "SpliceOutside", src: "(label xs [1 2 3]) ~@xs", wantErr: "unquote-splicing used outside of syntax-quote"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.RegisterAll(scope))
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasSuffix(err.Error(), tt.wantErr), "unexpected error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, fmt.Sprint(got))
+		})
+	}
+}
+
+func TestSyntaxQuote_Gensym(t *testing.T) {
+	t.Parallel()
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+
+	got, err := parens.ExecuteStr("[`(let [x# 1] x#) `x#]", scope)
+	require.NoError(t, err)
+
+	forms := got.(*parens.PersistentVector).ToSlice()
+	first, second := fmt.Sprint(forms[0]), fmt.Sprint(forms[1])
+
+	m := regexp.MustCompile(`^\(let \[(x__\d+__auto__) 1\] (x__\d+__auto__)\)$`).FindStringSubmatch(first)
+	require.Len(t, m, 3, "unexpected expansion: %s", first)
+	assert.Equal(t, m[1], m[2], "same symbol must be replaced consistently")
+	assert.Regexp(t, `^x__\d+__auto__$`, second)
+	assert.NotEqual(t, m[1], second, "every expansion must generate unique symbols")
+}
+
+func TestDefmacro(t *testing.T) {
+	t.Parallel()
+
+	const defs = `
+		(defmacro unless2 [c & body] ` + "`" + `(if ~c nil (do ~@body)))
+		(defmacro inc1 [x] ` + "`" + `(+ 1 ~x))
+		(defmacro inc2 [x] ` + "`" + `(inc1 (inc1 ~x)))
+	`
+
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr string
+	}{
+		{name: "Call", src: `[(unless2 false 1 2) (unless2 true 1)]`, want: "[2 <nil>]"},
+		{name: "ArgsNotEvaluated", src: `(unless2 true (throw (ex-info "boom" {})))`, want: "<nil>"},
+		{name: "Nested", src: `(inc2 40)`, want: "42"},
+		{name: "InFunction", src: `(defn f [x] (inc2 x)) [(f 1) (f 2)]`, want: "[3 4]"},
+		{name: "MacroExpand1", src: `(macroexpand-1 '(inc2 y))`, want: "(inc1 (inc1 y))"},
+		{name: "MacroExpand", src: `(macroexpand '(inc2 y))`, want: "(+ 1 (inc1 y))"},
+		{name: "MacroExpandRest", src: `(macroexpand '(unless2 false 1 2))`, want: "(if false nil (do 1 2))"},
+		{name: "MacroExpandNotMacro", src: `(macroexpand '(+ 1 2))`, want: "(+ 1 2)"},
+		{name: "MacroExpandArgs", src: `(macroexpand)`, wantErr: "exactly 1 argument required, got 0"},
+		{name: "MissingArgs", src: `(inc1)`, wantErr: "macro 'inc1': invalid number of arguments: requires 1 arguments, got 0"},
+		{name: "NotSymbol", src: `(defmacro "m" [x] x)`, wantErr: "first argument must be symbol, not 'parens.String'"},
+		{name: "NoBody", src: `(defmacro m [x])`, wantErr: "3 or more arguments required, got 2"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.RegisterAll(scope))
+
+			_, err := parens.ExecuteStr(defs, scope)
+			require.NoError(t, err)
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasSuffix(err.Error(), tt.wantErr), "unexpected error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, fmt.Sprint(got))
+		})
+	}
+}
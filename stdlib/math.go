@@ -2,7 +2,8 @@ package stdlib
 
 import (
 	"fmt"
-	"reflect"
+
+	"github.com/spy16/parens"
 )
 
 var math = []mapEntry{
@@ -103,7 +104,7 @@ func Eq(vals ...interface{}) bool {
 	lval := vals[0]
 
 	for i := 1; i < len(vals); i++ {
-		if !reflect.DeepEqual(lval, vals[i]) {
+		if !parens.Equal(lval, vals[i]) {
 			return false
 		}
 	}
@@ -114,13 +115,5 @@ func Eq(vals ...interface{}) bool {
 // Not returns true if val is nil or false value and false
 // otherwise.
 func Not(val interface{}) bool {
-	if b, ok := val.(bool); ok {
-		return !b
-	}
-
-	if val == nil {
-		return true
-	}
-
-	return false
+	return !parens.IsTruthy(val)
 }
@@ -0,0 +1,400 @@
+package stdlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/spy16/parens"
+)
+
+// ModuleRegistry tracks the namespaces of a program. Namespaces that are
+// not defined are created from the Go packages registered using
+// parens.RegisterPackage or loaded from files in the load path when
+// required. A namespace a.b-c is loaded from a/b-c.lisp relative to one of
+// the load path directories. Every module is loaded only once.
+type ModuleRegistry struct {
+	// Limits are enforced while loading each module. If not set, limits
+	// of the evaluation that requires the module first are used. In both
+	// cases, loading has its own budget since the modules are shared by
+	// all later evaluations.
+	Limits parens.Limits
+
+	core     parens.Scope
+	fs       FileSystem
+	loadPath []string
+
+	mu      sync.Mutex
+	modules map[string]*module
+	waiting map[string]string // module being loaded -> module it waits for
+}
+
+type module struct {
+	ns      *parens.Namespace
+	done    chan struct{}
+	err     error
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// loadingKey marks the context used for loading a module with its name so
+// that circular dependencies can be detected.
+type loadingKey struct{}
+
+// NewModuleRegistry returns a registry of namespaces with core as their
+// parent scope. Modules are loaded from the file system using the load
+// path. Current directory is used if no load path is given. File systems
+// must return errors satisfying os.IsNotExist for missing files.
+func NewModuleRegistry(core parens.Scope, fs FileSystem, loadPath ...string) *ModuleRegistry {
+	if len(loadPath) == 0 {
+		loadPath = []string{"."}
+	}
+
+	return &ModuleRegistry{
+		core:     core,
+		fs:       fs,
+		loadPath: loadPath,
+		modules:  map[string]*module{},
+		waiting:  map[string]string{},
+	}
+}
+
+// Define returns the namespace with the given name creating it if needed.
+// Defined namespaces can be required without loading any file and can be
+// used to expose Go functions as a module.
+func (mr *ModuleRegistry) Define(name string) *parens.Namespace {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if mod, found := mr.modules[name]; found {
+		return mod.ns
+	}
+
+	mod := &module{ns: parens.NewNamespace(name, mr.core), done: make(chan struct{})}
+	close(mod.done)
+	mr.modules[name] = mod
+	return mod.ns
+}
+
+// Require returns the namespace with the given name loading it from the
+// load path if it is not defined or loaded yet. If the namespace is being
+// loaded already, Require waits for it to complete. Error is returned if
+// waiting would lead to a circular dependency between modules. Loading is
+// not bound to ctx but is cancelled if ctx of every evaluation waiting for
+// the module is done before the module is loaded.
+func (mr *ModuleRegistry) Require(ctx context.Context, name string) (*parens.Namespace, error) {
+	loader, _ := ctx.Value(loadingKey{}).(string)
+
+	mr.mu.Lock()
+	mod, found := mr.modules[name]
+	if !found {
+		limits := mr.Limits
+		if limits == (parens.Limits{}) {
+			limits = parens.LimitsOf(ctx)
+		}
+
+		loadCtx, cancel := context.WithCancel(parens.Detach(ctx))
+		mod = &module{
+			ns:     parens.NewNamespace(name, mr.core),
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		mr.modules[name] = mod
+		go mr.load(loadCtx, mod, limits)
+	}
+
+	if loader != "" && !isDone(mod) {
+		if mr.waitsFor(name, loader) {
+			mr.mu.Unlock()
+			return nil, fmt.Errorf("circular dependency on namespace '%s'", name)
+		}
+
+		mr.waiting[loader] = name
+		defer func() {
+			mr.mu.Lock()
+			delete(mr.waiting, loader)
+			mr.mu.Unlock()
+		}()
+	}
+	mod.waiters++
+	mr.mu.Unlock()
+
+	select {
+	case <-mod.done:
+		mr.mu.Lock()
+		mod.waiters--
+		mr.mu.Unlock()
+
+		if mod.err != nil {
+			// the error is shared by all the requesters and must not be
+			// annotated by the evaluation of any one of them.
+			return nil, fmt.Errorf("%w", mod.err)
+		}
+		return mod.ns, nil
+
+	case <-ctx.Done():
+		mr.mu.Lock()
+		mod.waiters--
+		if mod.waiters == 0 && !isDone(mod) {
+			// nobody needs the module anymore. it is removed right away
+			// so that later requests start a fresh load.
+			mod.cancel()
+			mr.remove(mod)
+		}
+		mr.mu.Unlock()
+
+		return nil, ctx.Err()
+	}
+}
+
+// ResolveNamespace returns the namespace with the given name if it has been
+// defined or required already. It implements parens.NamespaceResolver.
+func (mr *ModuleRegistry) ResolveNamespace(name string) (*parens.Namespace, bool) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	mod, found := mr.modules[name]
+	if !found {
+		return nil, false
+	}
+	return mod.ns, true
+}
+
+// remove removes the module from the registry unless it has been replaced
+// already. mr.mu must be held.
+func (mr *ModuleRegistry) remove(mod *module) {
+	if mr.modules[mod.ns.Name()] == mod {
+		delete(mr.modules, mod.ns.Name())
+	}
+}
+
+// waitsFor returns true if the module (being loaded) is waiting for the
+// target module directly or through other modules. mr.mu must be held.
+func (mr *ModuleRegistry) waitsFor(module, target string) bool {
+	for name := module; name != ""; name = mr.waiting[name] {
+		if name == target {
+			return true
+		}
+	}
+	return false
+}
+
+func isDone(mod *module) bool {
+	select {
+	case <-mod.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Register binds ns and require into the scope. The registry is bound as
+// parens.NamespacesName to resolve qualified symbols using the full name of
+// namespaces. Register can be used as a Capability.
+func (mr *ModuleRegistry) Register(scope parens.Scope) error {
+	if err := scope.Bind(parens.NamespacesName, mr, "Namespaces defined or loaded so far"); err != nil {
+		return err
+	}
+
+	return registerList(scope, []mapEntry{
+		entry("ns", parens.MacroFunc(mr.nsMacro),
+			"Declares the namespace for the rest of the module and requires the",
+			"namespaces listed in the require clause. Definitions made after ns",
+			"go into the namespace.",
+			"Usage: (ns name (:require spec...))",
+		),
+		entry("require", parens.MacroFunc(mr.requireMacro),
+			"Loads the namespaces if not loaded already and binds them in the",
+			"current scope. A spec can be a namespace symbol or a vector of the",
+			"symbol followed by ':as alias' and/or ':refer [names...]'. Names in",
+			"a namespace can be accessed as ns/name or alias/name.",
+			"Usage: (require 'a.b '[c.d :as d :refer [f g]])",
+		),
+	})
+}
+
+// load loads the module using the context and limits and marks it done.
+// The module is removed from the registry if loading fails so that it can
+// be retried.
+func (mr *ModuleRegistry) load(ctx context.Context, mod *module, limits parens.Limits) {
+	defer mod.cancel()
+
+	err := mr.loadNamespace(context.WithValue(ctx, loadingKey{}, mod.ns.Name()), mod.ns, limits)
+
+	mr.mu.Lock()
+	mod.err = err
+	if err != nil {
+		mr.remove(mod)
+	}
+	close(mod.done)
+	mr.mu.Unlock()
+}
+
+func (mr *ModuleRegistry) loadNamespace(ctx context.Context, ns *parens.Namespace, limits parens.Limits) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("loading namespace '%s': %v", ns.Name(), v)
+		}
+	}()
+
+	if members, found := parens.LookupPackage(ns.Name()); found {
+		for name, val := range members {
+			if err := ns.Bind(name, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file := strings.ReplaceAll(ns.Name(), ".", "/") + ".lisp"
+	for _, dir := range mr.loadPath {
+		fh, err := mr.fs.Open(path.Join(dir, file))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		defer fh.Close()
+
+		_, err = parens.ExecuteWithOptions(fh, ns, parens.Options{
+			Context: ctx,
+			Limits:  limits,
+		})
+		return err
+	}
+
+	return fmt.Errorf("namespace '%s' not found in load path [%s]", ns.Name(), strings.Join(mr.loadPath, " "))
+}
+
+func (mr *ModuleRegistry) nsMacro(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	if len(exprs) < 1 {
+		return nil, errors.New("at-least 1 argument required")
+	}
+
+	sym, ok := exprs[0].(parens.Symbol)
+	if !ok {
+		return nil, fmt.Errorf("namespace name must be a symbol, not '%s'", reflect.TypeOf(exprs[0]))
+	}
+
+	ctx := parens.ContextOf(scope)
+	ns := mr.Define(sym.Value)
+	for _, expr := range exprs[1:] {
+		clause, ok := expr.(parens.List)
+		if !ok || len(clause.Forms) == 0 || !isKeyword(clause.Forms[0], ":require") {
+			return nil, fmt.Errorf("invalid ns clause '%s'", expr)
+		}
+
+		for _, spec := range clause.Forms[1:] {
+			if err := mr.requireSpec(ctx, ns, spec); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := parens.SwitchScope(scope, ns); err != nil {
+		return nil, err
+	}
+	return ns, nil
+}
+
+func (mr *ModuleRegistry) requireMacro(scope parens.Scope, exprs []parens.Expr) (interface{}, error) {
+	for _, expr := range exprs {
+		spec, err := expr.Eval(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		form, ok := spec.(parens.Expr)
+		if !ok {
+			return nil, fmt.Errorf("require spec must be a quoted symbol or vector, not '%s'", reflect.TypeOf(spec))
+		}
+
+		if err := mr.requireSpec(parens.ContextOf(scope), scope, form); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// requireSpec loads the namespace in the spec and binds it in the scope
+// using its name and the alias (if any). Referred names are bound directly.
+// ctx is the context of the evaluation requiring the namespace.
+func (mr *ModuleRegistry) requireSpec(ctx context.Context, scope parens.Scope, spec parens.Expr) error {
+	var opts []parens.Expr
+	if vec, ok := spec.(parens.Vector); ok && len(vec.Forms) > 0 {
+		spec, opts = vec.Forms[0], vec.Forms[1:]
+	}
+
+	sym, ok := spec.(parens.Symbol)
+	if !ok {
+		return fmt.Errorf("invalid require spec '%s'", spec)
+	} else if len(opts)%2 != 0 {
+		return fmt.Errorf("require options for '%s' must be key-value pairs", sym.Value)
+	}
+
+	ns, err := mr.Require(ctx, sym.Value)
+	if err != nil {
+		return err
+	}
+
+	if err := scope.Bind(sym.Value, ns); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(opts); i += 2 {
+		switch {
+		case isKeyword(opts[i], ":as"):
+			alias, ok := opts[i+1].(parens.Symbol)
+			if !ok {
+				return fmt.Errorf("alias must be a symbol, not '%s'", reflect.TypeOf(opts[i+1]))
+			}
+
+			if err := scope.Bind(alias.Value, ns); err != nil {
+				return err
+			}
+
+		case isKeyword(opts[i], ":refer"):
+			names, ok := opts[i+1].(parens.Vector)
+			if !ok {
+				return fmt.Errorf("refer must be a vector of symbols, not '%s'", reflect.TypeOf(opts[i+1]))
+			}
+
+			if err := refer(scope, ns, names.Forms); err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("unknown require option '%s'", opts[i])
+		}
+	}
+
+	return nil
+}
+
+func refer(scope parens.Scope, ns *parens.Namespace, names []parens.Expr) error {
+	for _, name := range names {
+		sym, ok := name.(parens.Symbol)
+		if !ok {
+			return fmt.Errorf("refer must be a vector of symbols, not '%s'", reflect.TypeOf(name))
+		}
+
+		val, err := ns.Own(sym.Value)
+		if err != nil {
+			return err
+		}
+
+		meta, _ := ns.Meta(sym.Value)
+		if err := bindMeta(scope, sym.Value, val, meta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
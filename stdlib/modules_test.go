@@ -0,0 +1,277 @@
+package stdlib_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spy16/parens"
+	"github.com/spy16/parens/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModules(t *testing.T) {
+	t.Parallel()
+
+	fs := mapFS{
+		"lib/text/util.lisp": `(ns text.util)
+		                       (defn parse "Splits s into words" [s] (words s))
+		                       (label version "1.0")`,
+		"lib/json.lisp": `(ns json (:require [text.util :as u]))
+		                  (defn parse [s] (count (u/parse s)))`,
+		"lib/counter.lisp": `(label loads (+ loads 1))`,
+		"lib/broken.lisp":  `(undefined-fn)`,
+		"lib/cycle/a.lisp": `(ns cycle.a (:require cycle.b))`,
+		"lib/cycle/b.lisp": `(ns cycle.b (:require cycle.a))`,
+		"lib/wrong.lisp":   `(ns wrong (:import foo))`,
+	}
+	parens.RegisterPackage("go.strings", map[string]interface{}{
+		"ToUpper": strings.ToUpper,
+		"Builder": reflect.TypeOf(strings.Builder{}),
+	})
+
+	tests := []struct {
+		name    string
+		src     string
+		want    interface{}
+		wantErr string
+	}{
+		{name: "FullName", src: `(require 'text.util) (text.util/parse "a b")`, want: []string{"a", "b"}},
+		{name: "Alias", src: `(require '[text.util :as u]) [(u/parse "a b c") u/version]`, want: []interface{}{[]string{"a", "b", "c"}, "1.0"}},
+		{name: "Refer", src: `(require '[text.util :refer [parse version]]) [(parse "x") version]`, want: []interface{}{[]string{"x"}, "1.0"}},
+		{name: "SameNameInDifferentNamespaces", src: `(require 'json 'text.util) [(json/parse "a b") (text.util/parse "a b")]`, want: []interface{}{int64(2), []string{"a", "b"}}},
+		{name: "NsDoesNotLeak", src: `(require 'json) (parse "a")`, wantErr: "name 'parse' not found"},
+		{name: "LoadedOnce", src: `(label loads 0) (require 'counter) (require 'counter) (require '[counter :as c]) [loads c/loads]`, want: []interface{}{int64(0), float64(1)}},
+		{name: "NsSwitches", src: `(ns app (:require [text.util :as u])) (defn parse [s] (u/parse s)) (parse "a b")`, want: []string{"a", "b"}},
+		{name: "NsSelfQualified", src: `(ns app) (defn parse [s] (words s)) (app/parse "a b")`, want: []string{"a", "b"}},
+		{name: "QualifiedFullName", src: `(ns other) (defn parse [] 1) (ns user) (other/parse)`, want: int64(1)},
+		{name: "NsGlobalStaysInNs", src: `(ns x) (global leaked 1) (ns y) [x/leaked (nil? (try leaked (catch :default e nil)))]`, want: []interface{}{int64(1), true}},
+		{name: "SetQualified", src: `(label loads 0) (require '[counter :as c]) (set! c/loads 5) [c/loads counter/loads loads]`, want: []interface{}{int64(5), int64(5), int64(0)}},
+		{name: "SetQualifiedUnknown", src: `(label loads 0) (require '[counter :as c]) (set! c/nope 1)`, wantErr: "name 'nope' not found in namespace 'counter'"},
+		{name: "NsInitialScopeUnchanged", src: `(ns app) (defn helper [] 1) (helper)`, want: int64(1)},
+		{name: "QualifiedUnknownName", src: `(require 'text.util) (text.util/unknown)`, wantErr: "name 'unknown' not found in namespace 'text.util'"},
+		{name: "QualifiedNotNamespace", src: `(label x 1) x/y`, wantErr: "name 'x/y' not found"},
+		{name: "DivisionSymbol", src: `(/ 6 3)`, want: float64(2)},
+		{name: "GoPackage", src: `(require '[go.strings :as s]) (s/ToUpper "x")`, want: "X"},
+		{name: "GoPackageType", src: `(require 'go.strings) (let [b (new go.strings/Builder)] (.WriteString b "hi") (.String b))`, want: "hi"},
+		{name: "NotFound", src: `(require 'missing)`, wantErr: "namespace 'missing' not found in load path [lib]"},
+		{name: "LoadError", src: `(require 'broken)`, wantErr: "name 'undefined-fn' not found"},
+		{name: "Circular", src: `(require 'cycle.a)`, wantErr: "circular dependency on namespace 'cycle.a'"},
+		{name: "InvalidClause", src: `(require 'wrong)`, wantErr: "invalid ns clause '(:import foo)'"},
+		{name: "InvalidSpec", src: `(require "json")`, wantErr: "require spec must be a quoted symbol or vector, not 'string'"},
+		{name: "UnknownOption", src: `(require '[json :only [parse]])`, wantErr: "unknown require option ':only'"},
+		{name: "ReferMissing", src: `(require '[json :refer [nope]])`, wantErr: "name 'nope' not found in namespace 'json'"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.Register(scope, stdlib.Pure, stdlib.WithModules(fs, "lib")))
+			scope.Bind("words", strings.Fields)
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasSuffix(err.Error(), tt.wantErr), "unexpected error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, parens.ToGo(got))
+		})
+	}
+}
+
+func TestModuleRegistry_Define(t *testing.T) {
+	t.Parallel()
+
+	scope := parens.NewScope(nil)
+	modules := stdlib.NewModuleRegistry(scope, mapFS{})
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure, modules.Register))
+
+	strs := modules.Define("strings")
+	strs.Bind("upper", strings.ToUpper, "Returns s in upper case")
+
+	got, err := parens.ExecuteStr(`(require '[strings :as s]) (s/upper "hello")`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO", got)
+
+	got, err = parens.ExecuteStr(`(require '[strings :as s]) (doc s/upper)`, scope)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(got.(string), "Returns s in upper case\n"), "unexpected doc: %v", got)
+	assert.True(t, strs == modules.Define("strings"))
+}
+
+func TestModuleRegistry_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	fs := mapFS{"slow.lisp": `(label loads (+ loads 1)) (defn answer [] 42)`}
+
+	global := parens.NewConcurrentScope(nil)
+	global.Bind("loads", int64(0))
+	modules := stdlib.NewModuleRegistry(global, fs)
+	require.NoError(t, stdlib.Register(global, stdlib.Pure, modules.Register))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			got, err := parens.ExecuteStr(fmt.Sprintf(`(require '[slow :as s%d]) (s%d/answer)`, i, i), global)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(42), got)
+		}(i)
+	}
+	wg.Wait()
+
+	ns, err := modules.Require(context.Background(), "slow")
+	require.NoError(t, err)
+	loads, err := ns.Own("loads")
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), loads)
+}
+
+func TestModuleRegistry_DetachedLoad(t *testing.T) {
+	t.Parallel()
+
+	fs := mapFS{
+		"util.lisp":  "(ns util) (defmacro twice [x] `(* 2 ~x))",
+		"heavy.lisp": `(ns heavy) (label x (loop [i 0] (if (< i 50) (recur (+ i 1)) i)))`,
+	}
+
+	scope := parens.NewScope(nil)
+	modules := stdlib.NewModuleRegistry(scope, fs)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure, modules.Register))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got, err := parens.ExecuteStrContext(ctx, `(require '[util :as u]) (u/twice 1)`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), got)
+	cancel()
+
+	got, err = parens.ExecuteStrContext(context.Background(), `(require '[util :as u]) (u/twice 2)`, scope)
+	require.NoError(t, err, "modules must not keep the context of the first requester")
+	assert.Equal(t, float64(4), got)
+
+	got, err = parens.ExecuteWithOptions(strings.NewReader(`
+		(loop [i 0] (if (< i 50) (recur (+ i 1)) i))
+		(require 'heavy)
+		heavy/x`), scope, parens.Options{
+		Limits: parens.Limits{MaxSteps: 300},
+	})
+	require.NoError(t, err, "loading must not use up the budget of the requester")
+	assert.Equal(t, float64(50), got)
+
+	inherited := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(inherited, stdlib.Pure, stdlib.WithModules(fs)))
+
+	_, err = parens.ExecuteWithOptions(strings.NewReader(`(require 'heavy)`), inherited, parens.Options{
+		Limits: parens.Limits{MaxSteps: 100},
+	})
+	assert.True(t, errors.Is(err, parens.ErrStepLimit), "loading must use the limits of the requester: %v", err)
+
+	limited := parens.NewScope(nil)
+	modules = stdlib.NewModuleRegistry(limited, fs)
+	modules.Limits = parens.Limits{MaxSteps: 10}
+	require.NoError(t, stdlib.Register(limited, stdlib.Pure, modules.Register))
+
+	_, err = parens.ExecuteStr(`(require 'heavy)`, limited)
+	assert.True(t, errors.Is(err, parens.ErrStepLimit), "unexpected error: %v", err)
+}
+
+func TestModuleRegistry_ConcurrentCircular(t *testing.T) {
+	t.Parallel()
+
+	fs := mapFS{
+		"a.lisp": `(ns a (:require b))`,
+		"b.lisp": `(ns b (:require a))`,
+	}
+
+	for i := 0; i < 20; i++ {
+		global := parens.NewConcurrentScope(nil)
+		modules := stdlib.NewModuleRegistry(global, fs)
+		require.NoError(t, stdlib.Register(global, stdlib.Pure, modules.Register))
+
+		errs := make(chan error, 2)
+		for _, name := range []string{"a", "b"} {
+			go func(name string) {
+				_, err := parens.ExecuteStr(fmt.Sprintf(`(require '%s)`, name), global)
+				errs <- err
+			}(name)
+		}
+
+		for j := 0; j < 2; j++ {
+			select {
+			case err := <-errs:
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "circular dependency on namespace")
+
+			case <-time.After(5 * time.Second):
+				t.Fatal("concurrent circular require did not complete")
+			}
+		}
+	}
+}
+
+func TestModuleRegistry_CancelledLoad(t *testing.T) {
+	t.Parallel()
+
+	fs := mapFS{
+		"forever.lisp": `(ns forever) (loop [i 0] (tick) (recur (+ i 1)))`,
+	}
+
+	var ticks int64
+	scope := parens.NewConcurrentScope(nil)
+	scope.Bind("tick", func() { atomic.AddInt64(&ticks, 1) })
+	modules := stdlib.NewModuleRegistry(scope, fs)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure, modules.Register))
+
+	requireForever := func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		_, err := parens.ExecuteStrContext(ctx, `(require 'forever)`, scope)
+		return err
+	}
+
+	// waitStopped waits until the module is not being loaded anymore.
+	waitStopped := func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for last := atomic.LoadInt64(&ticks); time.Now().Before(deadline); {
+			time.Sleep(20 * time.Millisecond)
+
+			cur := atomic.LoadInt64(&ticks)
+			if cur == last {
+				return
+			}
+			last = cur
+		}
+		t.Fatal("loading was not cancelled")
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- requireForever(time.Second) }()
+
+	assert.Equal(t, context.DeadlineExceeded, requireForever(20*time.Millisecond))
+	_, found := modules.ResolveNamespace("forever")
+	assert.True(t, found, "loading must continue while there are other requesters")
+
+	assert.Equal(t, context.DeadlineExceeded, <-errs)
+	waitStopped()
+	_, found = modules.ResolveNamespace("forever")
+	assert.False(t, found, "module must be removed once all the requesters give up")
+
+	before := atomic.LoadInt64(&ticks)
+	assert.Equal(t, context.DeadlineExceeded, requireForever(50*time.Millisecond))
+	assert.True(t, atomic.LoadInt64(&ticks) > before, "later require must load the module again")
+	waitStopped()
+}
@@ -0,0 +1,749 @@
+package stdlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spy16/parens"
+)
+
+var seq = []mapEntry{
+	entry("first", First,
+		"Returns the first item of the collection or nil if it is empty",
+		"Usage: (first coll)",
+	),
+	entry("rest", Rest,
+		"Returns the items after the first. Result is a lazy sequence if",
+		"coll is lazy and a vector otherwise.",
+		"Usage: (rest coll)",
+	),
+	entry("cons", Cons,
+		"Returns a new sequence with x followed by the items of coll. Result",
+		"is a lazy sequence if coll is lazy and a vector otherwise.",
+		"Usage: (cons x coll)",
+	),
+	entry("conj", Conj,
+		"Returns a new collection with the items added. Items are added at",
+		"the end of vectors and sequences and into sets and maps.",
+		"Usage: (conj coll x & xs)",
+	),
+	entry("count", Count,
+		"Returns the number of items in a collection, map or string",
+		"Usage: (count coll)",
+	),
+	entry("nth", Nth,
+		"Returns the item at index. Returns default if the index is out of",
+		"range and default is given. Throws error otherwise.",
+		"Usage: (nth coll index [default])",
+	),
+	entry("map", Map,
+		"Returns a lazy sequence of results of applying f to the items of",
+		"the collections. Stops when the shortest collection is exhausted.",
+		"Usage: (map f coll & colls)",
+	),
+	entry("filter", Filter,
+		"Returns a lazy sequence of items of coll for which pred returns",
+		"truthy",
+		"Usage: (filter pred coll)",
+	),
+	entry("reduce", Reduce,
+		"Reduces the collection using f starting with init (or the first",
+		"item if init is not given)",
+		"Usage: (reduce f coll) or (reduce f init coll)",
+	),
+	entry("range", Range,
+		"Returns a lazy sequence of numbers from start (inclusive, default 0)",
+		"to end (exclusive) by step (default 1). Sequence is infinite if no",
+		"arguments are given.",
+		"Usage: (range) or (range end) or (range start end [step])",
+	),
+	entry("take", Take,
+		"Returns a lazy sequence of first n items of the collection",
+		"Usage: (take n coll)",
+	),
+	entry("drop", Drop,
+		"Returns all but first n items of the collection. Result is a lazy",
+		"sequence if coll is lazy and a vector otherwise.",
+		"Usage: (drop n coll)",
+	),
+	entry("concat", Concat,
+		"Returns the items of all the collections in order. Result is a lazy",
+		"sequence if any of the collections is lazy and a vector otherwise.",
+		"Usage: (concat coll & colls)",
+	),
+	entry("sort", Sort,
+		"Returns a sorted vector of the items. Numbers, strings and keywords",
+		"are sorted in natural order. comp must return true (or a negative",
+		"number) if first argument should come before the second.",
+		"Usage: (sort coll) or (sort comp coll)",
+	),
+	entry("sort-by", SortBy,
+		"Returns a vector of the items sorted by the result of keyfn",
+		"Usage: (sort-by keyfn coll) or (sort-by keyfn comp coll)",
+	),
+	entry("group-by", GroupBy,
+		"Returns a map of results of f to vectors of items with that result",
+		"Usage: (group-by f coll)",
+	),
+	entry("distinct", Distinct,
+		"Returns a vector of the items with duplicates removed",
+		"Usage: (distinct coll)",
+	),
+	entry("reverse", Reverse,
+		"Returns a vector of the items in reverse order",
+		"Usage: (reverse coll)",
+	),
+}
+
+// First returns the first item of the collection or nil if it is empty.
+func First(ctx context.Context, coll interface{}) (interface{}, error) {
+	if vec, ok := coll.(*parens.PersistentVector); ok {
+		first, _ := vec.Nth(0)
+		return first, nil
+	}
+
+	s, err := seqOf(coll)
+	if err != nil {
+		return nil, err
+	}
+
+	first, _, _, err := uncons(ctx, s)
+	return first, err
+}
+
+// Rest returns the items of the collection after the first. Result is lazy
+// if the collection is lazy.
+func Rest(ctx context.Context, coll interface{}) (interface{}, error) {
+	return Drop(ctx, 1, coll)
+}
+
+// Cons returns a new sequence with x followed by the items of coll. Result
+// is lazy if the collection is lazy.
+func Cons(ctx context.Context, x interface{}, coll interface{}) (interface{}, error) {
+	s, err := seqOf(coll)
+	if err != nil {
+		return nil, err
+	} else if ls, ok := s.(*LazySeq); ok {
+		return lazyCons(x, ls), nil
+	}
+
+	items, _ := s.([]interface{})
+	return allocated(ctx, parens.NewVector(append([]interface{}{x}, items...)...))
+}
+
+// Conj returns a new collection with the xs added. Items are added at the
+// end of vectors and sequences and into sets. Items added to a map must
+// be [key value] vectors.
+func Conj(ctx context.Context, coll interface{}, xs ...interface{}) (interface{}, error) {
+	res, err := conj(ctx, coll, xs...)
+	if err != nil {
+		return nil, err
+	}
+	return allocated(ctx, res)
+}
+
+func conj(ctx context.Context, coll interface{}, xs ...interface{}) (interface{}, error) {
+	switch c := coll.(type) {
+	case *parens.PersistentVector:
+		return c.Conj(xs...), nil
+
+	case *parens.PersistentSet:
+		ts := c.Transient()
+		for _, x := range xs {
+			if err := ts.Conj(x); err != nil {
+				return nil, err
+			}
+		}
+		return ts.Persistent(), nil
+
+	case *parens.PersistentMap:
+		tm := c.Transient()
+		for _, x := range xs {
+			entry, ok := x.(*parens.PersistentVector)
+			if !ok || entry.Len() != 2 {
+				return nil, fmt.Errorf("conj on a map requires [key value] vectors, got '%v'", x)
+			}
+
+			key, _ := entry.Nth(0)
+			val, _ := entry.Nth(1)
+			if err := tm.Assoc(key, val); err != nil {
+				return nil, err
+			}
+		}
+		return tm.Persistent(), nil
+	}
+
+	items, err := seqItems(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	return parens.NewVector(items...).Conj(xs...), nil
+}
+
+// Count returns the number of items in the collection. For strings, the
+// number of characters is returned.
+func Count(ctx context.Context, coll interface{}) (int64, error) {
+	switch c := coll.(type) {
+	case *parens.PersistentVector:
+		return int64(c.Len()), nil
+
+	case *parens.PersistentMap:
+		return int64(c.Len()), nil
+
+	case *parens.PersistentSet:
+		return int64(c.Len()), nil
+	}
+
+	if s, ok := coll.(string); ok {
+		return int64(len([]rune(s))), nil
+	} else if rv := reflect.ValueOf(coll); rv.Kind() == reflect.Map {
+		return int64(rv.Len()), nil
+	}
+
+	items, err := seqItems(ctx, coll)
+	return int64(len(items)), err
+}
+
+// Nth returns the item at index. If index is out of range, def is returned
+// if given.
+func Nth(ctx context.Context, coll interface{}, index int64, def ...interface{}) (interface{}, error) {
+	if len(def) > 1 {
+		return nil, fmt.Errorf("at-most 1 default value allowed, got %d", len(def))
+	}
+
+	item, found, err := nthItem(ctx, coll, index)
+	if err != nil {
+		return nil, err
+	} else if found {
+		return item, nil
+	}
+
+	if len(def) == 1 {
+		return def[0], nil
+	}
+	return nil, fmt.Errorf("index %d out of range", index)
+}
+
+func nthItem(ctx context.Context, coll interface{}, index int64) (interface{}, bool, error) {
+	if vec, ok := coll.(*parens.PersistentVector); ok {
+		item, found := vec.Nth(int(index))
+		return item, found, nil
+	}
+
+	s, err := seqOf(coll)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for i := int64(0); index >= 0; i++ {
+		first, rest, ok, err := uncons(ctx, s)
+		if err != nil || !ok {
+			return nil, false, err
+		} else if i == index {
+			return first, true, nil
+		}
+		s = rest
+	}
+
+	return nil, false, nil
+}
+
+// Map returns a lazy sequence of results of applying f to the items of the
+// collections. If more than one collection is given, f is called with one
+// item from each and mapping stops when the shortest collection is
+// exhausted.
+func Map(f interface{}, colls ...interface{}) (*LazySeq, error) {
+	if len(colls) == 0 {
+		return nil, errors.New("at-least 1 collection required")
+	}
+
+	seqs := make([]interface{}, len(colls))
+	for i, coll := range colls {
+		s, err := seqOf(coll)
+		if err != nil {
+			return nil, err
+		}
+		seqs[i] = s
+	}
+
+	return mapSeq(f, seqs), nil
+}
+
+// Filter returns a lazy sequence of the items of coll for which pred returns
+// a truthy value.
+func Filter(pred interface{}, coll interface{}) (*LazySeq, error) {
+	s, err := seqOf(coll)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterSeq(pred, s), nil
+}
+
+// Reduce reduces the collection using f. Args can be (coll) or (init coll).
+// If init is not given, first item is used as init. If the collection is
+// also empty, result of calling f with no arguments is returned.
+func Reduce(ctx context.Context, f interface{}, args ...interface{}) (interface{}, error) {
+	var acc interface{}
+	var items []interface{}
+	var err error
+
+	switch len(args) {
+	case 1:
+		if items, err = seqItems(ctx, args[0]); err != nil {
+			return nil, err
+		}
+
+		if len(items) == 0 {
+			return parens.Call(ctx, f)
+		}
+		acc, items = items[0], items[1:]
+
+	case 2:
+		if items, err = seqItems(ctx, args[1]); err != nil {
+			return nil, err
+		}
+		acc = args[0]
+
+	default:
+		return nil, fmt.Errorf("2 or 3 arguments required, got %d", len(args)+1)
+	}
+
+	for _, item := range items {
+		if acc, err = parens.Call(ctx, f, acc, item); err != nil {
+			return nil, err
+		}
+	}
+
+	return acc, nil
+}
+
+// Range returns a lazy sequence of numbers from start (inclusive) to end
+// (exclusive) by step. Args can be () for an infinite sequence from 0,
+// (end), (start end) or (start end step). Result contains int64 values if
+// all args are integers and float64 values otherwise.
+func Range(args ...interface{}) (*LazySeq, error) {
+	if len(args) > 3 {
+		return nil, fmt.Errorf("at-most 3 arguments allowed, got %d", len(args))
+	}
+
+	nums := []float64{0, 0, 1}
+	allInts := true
+	offset := 0
+	if len(args) == 1 {
+		offset = 1
+	}
+
+	for i, arg := range args {
+		switch n := arg.(type) {
+		case int64:
+			nums[i+offset] = float64(n)
+
+		case float64:
+			nums[i+offset] = n
+			allInts = false
+
+		default:
+			return nil, fmt.Errorf("range requires numbers, not '%s'", reflect.TypeOf(arg))
+		}
+	}
+
+	if nums[2] == 0 {
+		return nil, errors.New("step must not be zero")
+	}
+
+	var end *float64
+	if len(args) > 0 {
+		end = &nums[1]
+	}
+	return rangeSeq(nums[0], nums[2], end, allInts), nil
+}
+
+// Take returns a lazy sequence of the first n items of the collection.
+func Take(n int64, coll interface{}) (*LazySeq, error) {
+	s, err := seqOf(coll)
+	if err != nil {
+		return nil, err
+	}
+
+	return takeSeq(n, s), nil
+}
+
+// Drop returns all but the first n items of the collection. Result is lazy
+// if the collection is lazy.
+func Drop(ctx context.Context, n int64, coll interface{}) (interface{}, error) {
+	s, err := seqOf(coll)
+	if err != nil {
+		return nil, err
+	} else if ls, ok := s.(*LazySeq); ok {
+		return dropSeq(n, ls), nil
+	}
+
+	items, _ := s.([]interface{})
+	if n < 0 {
+		n = 0
+	} else if n > int64(len(items)) {
+		n = int64(len(items))
+	}
+
+	return allocated(ctx, parens.NewVector(items[n:]...))
+}
+
+// Concat returns the items of all the collections in order. Result is lazy
+// if any of the collections is lazy.
+func Concat(ctx context.Context, colls ...interface{}) (interface{}, error) {
+	seqs := make([]interface{}, len(colls))
+	isLazy := false
+	for i, coll := range colls {
+		s, err := seqOf(coll)
+		if err != nil {
+			return nil, err
+		}
+
+		_, ok := s.(*LazySeq)
+		seqs[i], isLazy = s, isLazy || ok
+	}
+
+	if isLazy {
+		return concatSeq(seqs), nil
+	}
+
+	res := (&parens.PersistentVector{}).Transient()
+	for _, s := range seqs {
+		items, _ := s.([]interface{})
+		for _, item := range items {
+			res.Conj(item)
+		}
+	}
+	return allocated(ctx, res.Persistent())
+}
+
+// Sort returns the items sorted in natural order or using the comparator.
+// Args can be (coll) or (comp coll).
+func Sort(ctx context.Context, args ...interface{}) (*parens.PersistentVector, error) {
+	switch len(args) {
+	case 1:
+		return sortItems(ctx, args[0], nil, nil)
+
+	case 2:
+		return sortItems(ctx, args[1], nil, args[0])
+
+	default:
+		return nil, fmt.Errorf("1 or 2 arguments required, got %d", len(args))
+	}
+}
+
+// SortBy returns the items sorted by the results of keyfn. Args can be
+// (coll) or (comp coll).
+func SortBy(ctx context.Context, keyfn interface{}, args ...interface{}) (*parens.PersistentVector, error) {
+	switch len(args) {
+	case 1:
+		return sortItems(ctx, args[0], keyfn, nil)
+
+	case 2:
+		return sortItems(ctx, args[1], keyfn, args[0])
+
+	default:
+		return nil, fmt.Errorf("2 or 3 arguments required, got %d", len(args)+1)
+	}
+}
+
+// GroupBy groups the items of the collection by the results of f.
+func GroupBy(ctx context.Context, f interface{}, coll interface{}) (*parens.PersistentMap, error) {
+	items, err := seqItems(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := (&parens.PersistentMap{}).Transient()
+	for _, item := range items {
+		key, err := parens.Call(ctx, f, item)
+		if err != nil {
+			return nil, err
+		}
+
+		group, found := groups.Get(key)
+		if !found {
+			group = (&parens.PersistentVector{}).Transient()
+			if err := groups.Assoc(key, group); err != nil {
+				return nil, fmt.Errorf("invalid group key: %w", err)
+			}
+		}
+		group.(*parens.TransientVector).Conj(item)
+	}
+
+	res := groups.Persistent()
+	for _, key := range res.Keys() {
+		group, _ := res.Get(key)
+		res, _ = res.Assoc(key, group.(*parens.TransientVector).Persistent())
+	}
+
+	if err := parens.Allocate(ctx, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Distinct returns the items with duplicates removed. Order of the first
+// occurrences is retained.
+func Distinct(ctx context.Context, coll interface{}) (*parens.PersistentVector, error) {
+	items, err := seqItems(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := (&parens.PersistentSet{}).Transient()
+	var unhashable []interface{}
+	res := (&parens.PersistentVector{}).Transient()
+	for _, item := range items {
+		if _, err := parens.Hash(item); err == nil {
+			if !seen.Contains(item) {
+				_ = seen.Conj(item)
+				res.Conj(item)
+			}
+			continue
+		}
+
+		duplicate := false
+		for _, existing := range unhashable {
+			if parens.Equal(existing, item) {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			unhashable = append(unhashable, item)
+			res.Conj(item)
+		}
+	}
+
+	return allocatedVector(ctx, res.Persistent())
+}
+
+// Reverse returns the items in reverse order.
+func Reverse(ctx context.Context, coll interface{}) (*parens.PersistentVector, error) {
+	items, err := seqItems(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	res := (&parens.PersistentVector{}).Transient()
+	for i := len(items) - 1; i >= 0; i-- {
+		res.Conj(items[i])
+	}
+	return allocatedVector(ctx, res.Persistent())
+}
+
+func sortItems(ctx context.Context, coll interface{}, keyfn interface{}, comp interface{}) (*parens.PersistentVector, error) {
+	items, err := seqItems(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := items
+	if keyfn != nil {
+		keys = make([]interface{}, len(items))
+		for i, item := range items {
+			if keys[i], err = parens.Call(ctx, keyfn, item); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	idx := make([]int, len(items))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	var sortErr error
+	sort.SliceStable(idx, func(i, j int) bool {
+		if sortErr != nil {
+			return false
+		}
+
+		var less bool
+		less, sortErr = compareLess(ctx, comp, keys[idx[i]], keys[idx[j]])
+		return less
+	})
+
+	if sortErr != nil {
+		return nil, sortErr
+	}
+
+	res := (&parens.PersistentVector{}).Transient()
+	for _, k := range idx {
+		res.Conj(items[k])
+	}
+	return allocatedVector(ctx, res.Persistent())
+}
+
+// compareLess returns true if a should come before b. If comp is nil, the
+// natural order is used.
+func compareLess(ctx context.Context, comp interface{}, a, b interface{}) (bool, error) {
+	if comp == nil {
+		c, err := compare(a, b)
+		return c < 0, err
+	}
+
+	res, err := parens.Call(ctx, comp, a, b)
+	if err != nil {
+		return false, err
+	}
+
+	if n, ok := numberOf(res); ok {
+		return n < 0, nil
+	}
+
+	return parens.IsTruthy(res), nil
+}
+
+// compare compares numbers, strings and keywords in their natural order.
+func compare(a, b interface{}) (int, error) {
+	if af, ok := numberOf(a); ok {
+		if bf, ok := numberOf(b); ok {
+			switch {
+			case af < bf:
+				return -1, nil
+			case af > bf:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if ak, ok := a.(parens.Keyword); ok {
+		as, aok = string(ak), true
+	}
+	if bk, ok := b.(parens.Keyword); ok {
+		bs, bok = string(bk), true
+	}
+
+	if aok && bok && reflect.TypeOf(a) == reflect.TypeOf(b) {
+		return strings.Compare(as, bs), nil
+	}
+
+	return 0, fmt.Errorf("cannot compare '%s' and '%s'", reflect.TypeOf(a), reflect.TypeOf(b))
+}
+
+// numberOf returns the value of any Go number as float64.
+func numberOf(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+
+	return 0, false
+}
+
+// seqItems returns all the items of a collection. Lazy sequences are
+// realized fully.
+func seqItems(ctx context.Context, coll interface{}) ([]interface{}, error) {
+	s, err := seqOf(coll)
+	if err != nil {
+		return nil, err
+	} else if ls, ok := s.(*LazySeq); ok {
+		return ls.Realize(ctx)
+	}
+
+	items, _ := s.([]interface{})
+	return items, nil
+}
+
+// seqOf returns the collection as a Go slice or a lazy sequence. Collection
+// can be nil, a vector, any Go slice or array, a string (items are
+// characters), a set (items are in no particular order), a map (items are
+// [key value] vectors in no particular order), a lazy sequence, an Iterator
+// or a channel (items are received until it is closed).
+func seqOf(coll interface{}) (interface{}, error) {
+	switch c := coll.(type) {
+	case nil, []interface{}, *LazySeq:
+		return c, nil
+
+	case *parens.PersistentVector:
+		return c.ToSlice(), nil
+
+	case *parens.PersistentSet:
+		return c.ToSlice(), nil
+
+	case *parens.PersistentMap:
+		items := make([]interface{}, 0, c.Len())
+		c.Range(func(key, val interface{}) bool {
+			items = append(items, parens.NewVector(key, val))
+			return true
+		})
+		return items, nil
+
+	case Iterator:
+		return NewLazySeq(c), nil
+	}
+
+	rv := reflect.ValueOf(coll)
+	switch rv.Kind() {
+	case reflect.String:
+		items := []interface{}{}
+		for _, r := range rv.String() {
+			items = append(items, r)
+		}
+		return items, nil
+
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+		return items, nil
+
+	case reflect.Map:
+		isSet := rv.Type().Elem() == reflect.TypeOf(struct{}{})
+
+		items := make([]interface{}, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			if isSet {
+				items = append(items, iter.Key().Interface())
+			} else {
+				items = append(items, parens.NewVector(iter.Key().Interface(), iter.Value().Interface()))
+			}
+		}
+		return items, nil
+
+	case reflect.Chan:
+		if rv.Type().ChanDir()&reflect.RecvDir != 0 {
+			return NewLazySeq(chanIterator{ch: rv}), nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot use '%s' as a sequence", reflect.TypeOf(coll))
+}
+
+// uncons returns the first item and the rest of a sequence returned by
+// seqOf. ok is false if the sequence is empty.
+func uncons(ctx context.Context, s interface{}) (first, rest interface{}, ok bool, err error) {
+	switch c := s.(type) {
+	case *LazySeq:
+		return c.uncons(ctx)
+
+	case []interface{}:
+		if len(c) == 0 {
+			return nil, nil, false, nil
+		}
+		return c[0], c[1:], true, nil
+	}
+
+	return nil, nil, false, nil
+}
@@ -0,0 +1,186 @@
+package stdlib_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spy16/parens"
+	"github.com/spy16/parens/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeq(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		want    interface{}
+		wantErr string
+	}{
+		{name: "First", src: `(first [1 2])`, want: int64(1)},
+		{name: "FirstEmpty", src: `(first [])`, want: nil},
+		{name: "FirstNil", src: `(first nil)`, want: nil},
+		{name: "FirstGoSlice", src: `(first (go-ints))`, want: 1},
+		{name: "Rest", src: `(rest [1 2 3])`, want: []interface{}{int64(2), int64(3)}},
+		{name: "RestEmpty", src: `(rest [])`, want: []interface{}{}},
+		{name: "Cons", src: `(cons 0 [1])`, want: []interface{}{int64(0), int64(1)}},
+		{name: "Conj", src: `(conj [1] 2 3)`, want: []interface{}{int64(1), int64(2), int64(3)}},
+		{name: "Count", src: `[(count [1 2]) (count "héllo") (count {:a 1}) (count nil) (count (go-ints))]`,
+			want: []interface{}{int64(2), int64(5), int64(1), int64(0), int64(3)}},
+		{name: "Nth", src: `(nth [1 2] 1)`, want: int64(2)},
+		{name: "NthDefault", src: `(nth [1 2] 5 :none)`, want: parens.Keyword(":none")},
+		{name: "NthOutOfRange", src: `(nth [1 2] 5)`, wantErr: "index 5 out of range"},
+		{name: "Map", src: `(map (lambda [x] (* x 2)) [1 2])`, want: []interface{}{float64(2), float64(4)}},
+		{name: "MapMultiple", src: `(map (lambda [a b] [a b]) [1 2 3] [:a :b])`,
+			want: []interface{}{
+				[]interface{}{int64(1), parens.Keyword(":a")},
+				[]interface{}{int64(2), parens.Keyword(":b")},
+			}},
+		{name: "MapGoFunc", src: `(map go-double (go-ints))`, want: []interface{}{2, 4, 6}},
+		{name: "Filter", src: `(filter (lambda [x] (> x 1)) (go-ints))`, want: []interface{}{2, 3}},
+		{name: "FilterTruthiness", src: `(filter (lambda [x] x) [1 nil false 0])`, want: []interface{}{int64(1), int64(0)}},
+		{name: "Reduce", src: `(reduce + [1 2 3])`, want: float64(6)},
+		{name: "ReduceInit", src: `(reduce conj [] [1 2])`, want: []interface{}{int64(1), int64(2)}},
+		{name: "ReduceEmpty", src: `(reduce (lambda [] :empty) [])`, want: parens.Keyword(":empty")},
+		{name: "Range", src: `(range 3)`, want: []interface{}{int64(0), int64(1), int64(2)}},
+		{name: "RangeStep", src: `(range 5 0 -2)`, want: []interface{}{int64(5), int64(3), int64(1)}},
+		{name: "RangeFloat", src: `(range 0 1 0.5)`, want: []interface{}{float64(0), float64(0.5)}},
+		{name: "RangeZeroStep", src: `(range 0 1 0)`, wantErr: "step must not be zero"},
+		{name: "Take", src: `(take 2 (range 10))`, want: []interface{}{int64(0), int64(1)}},
+		{name: "TakeMore", src: `(take 5 [1])`, want: []interface{}{int64(1)}},
+		{name: "Drop", src: `(drop 2 [1 2 3])`, want: []interface{}{int64(3)}},
+		{name: "Concat", src: `(concat [1] (go-ints) nil)`, want: []interface{}{int64(1), 1, 2, 3}},
+		{name: "Sort", src: `(sort [3 1.5 2])`, want: []interface{}{float64(1.5), int64(2), int64(3)}},
+		{name: "SortStrings", src: `(sort ["b" "a"])`, want: []interface{}{"a", "b"}},
+		{name: "SortComparator", src: `(sort > (go-ints))`, want: []interface{}{3, 2, 1}},
+		{name: "SortMixed", src: `(sort [1 "a"])`, wantErr: "cannot compare 'string' and 'int64'"},
+		{name: "SortBy", src: `(sort-by (lambda [m] (get m :age)) [{:age 30} {:age 20}])`,
+			want: []interface{}{
+				map[interface{}]interface{}{parens.Keyword(":age"): int64(20)},
+				map[interface{}]interface{}{parens.Keyword(":age"): int64(30)},
+			}},
+		{name: "GroupBy", src: `(group-by (lambda [x] (> x 1)) [1 2 3])`,
+			want: map[interface{}]interface{}{
+				false: []interface{}{int64(1)},
+				true:  []interface{}{int64(2), int64(3)},
+			}},
+		{name: "Distinct", src: `(distinct [1 2 1 [3] [3]])`, want: []interface{}{int64(1), int64(2), []interface{}{int64(3)}}},
+		{name: "Reverse", src: `(reverse (go-ints))`, want: []interface{}{3, 2, 1}},
+		{name: "NotASequence", src: `(first 1)`, wantErr: "cannot use 'int64' as a sequence"},
+		{name: "RangeInfinite", src: `(take 3 (range))`, want: []interface{}{int64(0), int64(1), int64(2)}},
+		{name: "LazySeq", src: `(defn nat [n] (lazy-seq (cons n (nat (+ n 1)))))
+                               (take 2 (drop 5 (nat 0)))`,
+			want: []interface{}{float64(5), float64(6)}},
+		{name: "LazySeqNotEvaluated", src: `(do (lazy-seq (undefined)) :ok)`, want: parens.Keyword(":ok")},
+		{name: "LazySeqError", src: `(first (lazy-seq (undefined)))`, wantErr: "name 'undefined' not found"},
+		{name: "LazySeqEmpty", src: `[(first (lazy-seq nil)) (count (lazy-seq []))]`, want: []interface{}{nil, int64(0)}},
+		{name: "LazyMapFilter", src: `(take 3 (filter (lambda [x] (> x 10)) (map (lambda [x] (* x x)) (range))))`,
+			want: []interface{}{float64(16), float64(25), float64(36)}},
+		{name: "LazyNth", src: `(nth (range) 1000)`, want: int64(1000)},
+		{name: "LazyConcat", src: `(take 3 (concat [:a] (range)))`, want: []interface{}{parens.Keyword(":a"), int64(0), int64(1)}},
+		{name: "LazyRest", src: `(first (rest (range)))`, want: int64(1)},
+		{name: "LazyDestructure", src: `(let [[a b & more] (range)] [a b (first more)])`,
+			want: []interface{}{int64(0), int64(1), int64(2)}},
+		{name: "Iterate", src: `(take 4 (iterate (lambda [x] (* x 2)) 1))`,
+			want: []interface{}{int64(1), float64(2), float64(4), float64(8)}},
+		{name: "Repeat", src: `(take 2 (repeat :x))`, want: []interface{}{parens.Keyword(":x"), parens.Keyword(":x")}},
+		{name: "RepeatN", src: `(repeat 2 "a")`, want: []interface{}{"a", "a"}},
+		{name: "Cycle", src: `(take 5 (cycle [1 2]))`, want: []interface{}{int64(1), int64(2), int64(1), int64(2), int64(1)}},
+		{name: "CycleEmpty", src: `(cycle [])`, want: []interface{}{}},
+		{name: "Vec", src: `(vec (map inc-go (range 3)))`, want: []interface{}{1, 2, 3}},
+		{name: "LazyToGoSlice", src: `(sum-go (take 3 (range)))`, want: 3},
+		{name: "LazyRealizedOnce", src: `(let [s (map counter (range 3))] (vec s) (vec s) (counter 0))`, want: int64(4)},
+		{name: "Iterator", src: `(vec (go-iter))`, want: []interface{}{0, 1, 2}},
+		{name: "Channel", src: `(reduce + (go-chan))`, want: float64(3)},
+		{name: "SelfDependent", src: `(label s (lazy-seq (first s))) (first s)`, wantErr: "lazy sequence depends on itself"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			scope := parens.NewScope(nil)
+			require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+			scope.Bind("go-ints", func() []int { return []int{1, 2, 3} })
+			scope.Bind("go-double", func(i int) int { return i * 2 })
+			scope.Bind("inc-go", func(i int) int { return i + 1 })
+			scope.Bind("sum-go", func(items []int) int {
+				sum := 0
+				for _, item := range items {
+					sum += item
+				}
+				return sum
+			})
+
+			calls := int64(0)
+			scope.Bind("counter", func(_ interface{}) int64 {
+				calls++
+				return calls
+			})
+			scope.Bind("go-iter", func() stdlib.Iterator {
+				i := 0
+				return stdlib.IteratorFunc(func(ctx context.Context) (interface{}, bool, error) {
+					i++
+					return i - 1, i <= 3, nil
+				})
+			})
+			scope.Bind("go-chan", func() <-chan int {
+				ch := make(chan int, 3)
+				ch <- 0
+				ch <- 1
+				ch <- 2
+				close(ch)
+				return ch
+			})
+
+			got, err := parens.ExecuteStr(tt.src, scope)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasSuffix(err.Error(), tt.wantErr), "unexpected error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			if ls, ok := got.(*stdlib.LazySeq); ok {
+				got, err = ls.Realize(context.Background())
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, parens.ToGo(got))
+		})
+	}
+}
+
+func TestLazySeq_Limits(t *testing.T) {
+	t.Parallel()
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+
+	_, err := parens.ExecuteWithOptions(strings.NewReader(`(count (range))`), scope, parens.Options{
+		Limits: parens.Limits{MaxSteps: 1000},
+	})
+	assert.True(t, errors.Is(err, parens.ErrStepLimit), "unexpected error: %v", err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = parens.ExecuteStrContext(ctx, `(reduce + (filter (lambda [x] false) (range)))`, scope)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+}
+
+func TestLazySeq_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "(0 1 2)", mustRange(t, int64(3)).String())
+	assert.True(t, strings.HasSuffix(mustRange(t).String(), " 31 ...)"))
+}
+
+func mustRange(t *testing.T, args ...interface{}) *stdlib.LazySeq {
+	ls, err := stdlib.Range(args...)
+	require.NoError(t, err)
+	return ls
+}
@@ -1,15 +1,94 @@
 package stdlib
 
-import "github.com/spy16/parens"
+import (
+	"io"
+	"os"
+
+	"github.com/spy16/parens"
+)
+
+// Capability registers a group of functions into the scope. Capabilities
+// can be combined using Register to control what is available to the
+// expressions being evaluated.
+type Capability func(scope parens.Scope) error
+
+var (
+	// Pure provides the core macros and functions, math operators and
+	// collection functions. None of these have access to the host.
+	Pure Capability = RegisterPure
+
+	// IO provides printing functions writing to os.Stdout and read which
+	// reads from os.Stdin. Use WithIO for other reader and writer.
+	IO = WithIO(os.Stdin, os.Stdout)
+
+	// FS provides load which reads files from the host file system. Use
+	// WithFS for a restricted or virtual file system.
+	FS = WithFS(OSFileSystem{})
+
+	// Env provides functions to read and modify the environment variables.
+	Env Capability = RegisterSystem
+
+	// Modules provides ns and require which load namespaces from the files
+	// in the current directory of the host. Use WithModules for a different
+	// file system or load path.
+	Modules = WithModules(OSFileSystem{})
+)
+
+// Register registers the functions provided by all of the capabilities
+// into the scope.
+func Register(scope parens.Scope, caps ...Capability) error {
+	for _, capability := range caps {
+		if err := capability(scope); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WithIO returns the IO capability using the reader for input and the
+// writer for all output.
+func WithIO(in io.Reader, out io.Writer) Capability {
+	return func(scope parens.Scope) error {
+		return registerList(scope, ioEntries(in, out))
+	}
+}
+
+// WithFS returns the FS capability using the given file system.
+func WithFS(fs FileSystem) Capability {
+	return func(scope parens.Scope) error {
+		return scope.Bind("load", LoadFileFrom(fs, scope),
+			"Reads and executes the file in the current scope",
+			"Example: (load \"sample.lisp\")",
+		)
+	}
+}
+
+// WithModules returns the Modules capability loading namespaces from the
+// file system using the load path. The scope the capability is registered
+// into is used as the parent scope of all the namespaces. Use
+// ModuleRegistry directly to define namespaces from Go.
+func WithModules(fs FileSystem, loadPath ...string) Capability {
+	return func(scope parens.Scope) error {
+		return NewModuleRegistry(scope, fs, loadPath...).Register(scope)
+	}
+}
 
 // RegisterAll registers different built-in functions into the
-// given scope.
+// given scope. This is same as registering all of Pure, IO, FS, Env
+// and Modules capabilities.
 func RegisterAll(scope parens.Scope) error {
+	return Register(scope, Pure, IO, FS, Env, Modules)
+}
+
+// RegisterPure registers all the functions that do not access the host
+// into the scope.
+func RegisterPure(scope parens.Scope) error {
 	return doUntilErr(scope,
 		RegisterCore,
 		RegisterMath,
-		RegisterIO,
-		RegisterSystem,
+		RegisterCollections,
+		RegisterSeq,
 	)
 }
 
@@ -18,25 +97,47 @@ func RegisterSystem(scope parens.Scope) error {
 	return registerList(scope, system)
 }
 
-// RegisterIO binds input/output functions into the scope.
+// RegisterIO binds input/output functions using os.Stdin and os.Stdout
+// into the scope.
 func RegisterIO(scope parens.Scope) error {
-	return registerList(scope, io)
+	return IO(scope)
 }
 
 // RegisterCore binds all the core macros and functions into
-// the scope.
+// the scope. Use FS capability for load.
 func RegisterCore(scope parens.Scope) error {
 	scope.Bind("eval", Eval(scope),
 		"Executes given LISP string in the current scope",
 		"Usage: (eval <form>)",
 	)
 
-	scope.Bind("load", LoadFile(scope),
-		"Reads and executes the file in the current scope",
-		"Example: (load \"sample.lisp\")",
-	)
+	if err := registerList(scope, core); err != nil {
+		return err
+	}
+
+	if err := registerList(scope, exceptions); err != nil {
+		return err
+	}
+
+	return registerList(scope, macros)
+}
+
+// RegisterCollections binds functions for working with maps and vectors
+// into the scope.
+func RegisterCollections(scope parens.Scope) error {
+	return registerList(scope, collections)
+}
+
+// RegisterSeq binds sequence functions (e.g., map, filter, reduce) and
+// lazy sequence functions (e.g., lazy-seq, iterate) into the scope. These
+// accept vectors, lazy sequences, channels, Iterators as well as any Go
+// slice or array.
+func RegisterSeq(scope parens.Scope) error {
+	if err := registerList(scope, seq); err != nil {
+		return err
+	}
 
-	return registerList(scope, core)
+	return registerList(scope, lazy)
 }
 
 // RegisterMath binds basic math operators into the scope.
@@ -0,0 +1,161 @@
+package stdlib_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spy16/parens"
+	"github.com/spy16/parens/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister_Pure(t *testing.T) {
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+
+	for _, name := range []string{"load", "println", "read", "inspect", "env", "set-env", "ns", "require", parens.NamespacesName} {
+		_, err := scope.Get(name)
+		assert.Error(t, err, "'%s' must not be bound", name)
+	}
+
+	got, err := parens.ExecuteStr("(+ 1 2)", scope)
+	require.NoError(t, err)
+	assert.Equal(t, float64(3), got)
+}
+
+func TestRegister_WithIO(t *testing.T) {
+	in := strings.NewReader("hello\nworld")
+	out := &bytes.Buffer{}
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure, stdlib.WithIO(in, out)))
+
+	got, err := parens.ExecuteStr(`(do (println "first:" (read)) (printf "second:%s" (read)))`, scope)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	assert.Equal(t, "first: hello\nsecond:world", out.String())
+}
+
+func TestRegister_WithFS(t *testing.T) {
+	fs := mapFS{"lib.lisp": `(label answer 42)`}
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure, stdlib.WithFS(fs)))
+
+	got, err := parens.ExecuteStr(`(do (load "lib.lisp") answer)`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), got)
+
+	_, err = parens.ExecuteStr(`(load "/etc/passwd")`, scope)
+	assert.Error(t, err)
+}
+
+type mapFS map[string]string
+
+func (fs mapFS) Open(name string) (io.ReadCloser, error) {
+	src, found := fs[name]
+	if !found {
+		return nil, os.ErrNotExist
+	}
+
+	return ioutil.NopCloser(strings.NewReader(src)), nil
+}
+
+func TestInterop(t *testing.T) {
+	type config struct {
+		Name  string
+		Level int
+	}
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+	scope.Bind("Config", reflect.TypeOf(config{}))
+
+	got, err := parens.ExecuteStr(`(do
+		(label c (new Config :Name "test"))
+		(set-field! c :Level 3)
+		c)`, scope)
+	require.NoError(t, err)
+	assert.Equal(t, &config{Name: "test", Level: 3}, got)
+
+	_, err = parens.ExecuteStr(`(set-field! c :Missing 1)`, scope)
+	assert.True(t, errors.Is(err, parens.ErrNoSuchMember))
+}
+
+func TestInterop_Converters(t *testing.T) {
+	type job struct {
+		Timeout time.Duration
+	}
+
+	convs := parens.NewConverters()
+	convs.Register(reflect.TypeOf(time.Duration(0)), func(v interface{}) (interface{}, error) {
+		return time.ParseDuration(v.(string))
+	})
+
+	scope := parens.NewScope(nil)
+	require.NoError(t, stdlib.Register(scope, stdlib.Pure))
+	scope.Bind("Job", reflect.TypeOf(job{}))
+
+	got, err := parens.ExecuteWithOptions(strings.NewReader(`[(new Job :Timeout "1s") (set-field! (new Job) :Timeout "2m")]`), scope, parens.Options{
+		Converters: convs,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{&job{Timeout: time.Second}, "2m"}, parens.ToGo(got))
+
+	got, err = parens.ExecuteWithOptions(strings.NewReader(`(label j (new Job)) (set-field! j :Timeout "1m") j`), scope, parens.Options{
+		Converters: convs,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &job{Timeout: time.Minute}, got)
+}
+
+func TestConcurrentExecution(t *testing.T) {
+	out := &bytes.Buffer{}
+
+	global := parens.NewConcurrentScope(nil)
+	require.NoError(t, stdlib.Register(global, stdlib.Pure, stdlib.WithIO(strings.NewReader(""), out)))
+
+	_, err := parens.ExecuteStr(`(do
+		(defn square [x] (* x x))
+		(defn inc-double [x] (-> x (+ 1) (* 2)))
+		(label squares (map square (range))))`, global)
+	require.NoError(t, err)
+
+	const workers = 16
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			src := fmt.Sprintf(`(do
+				(label result-%d (reduce + (take 100 squares)))
+				(let [{:keys [a b]} {:a %d :b (nth squares %d)}]
+					(println a b))
+				[result-%d (inc-double %d)])`, i, i, i, i, i)
+
+			got, err := parens.ExecuteStr(src, global)
+			assert.NoError(t, err)
+			assert.Equal(t, []interface{}{float64(328350), float64(2 * (i + 1))}, parens.ToGo(got))
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < workers; i++ {
+		got, err := global.Get(fmt.Sprintf("result-%d", i))
+		assert.NoError(t, err)
+		assert.Equal(t, float64(328350), got)
+		assert.Contains(t, out.String(), fmt.Sprintf("%d %v\n", i, float64(i*i)))
+	}
+}
@@ -14,10 +14,10 @@ var system = []mapEntry{
 	),
 }
 
-func setenv(name, val string) string {
+func setenv(name, val string) (string, error) {
 	if err := os.Setenv(name, val); err != nil {
-		panic(err)
+		return "", err
 	}
 
-	return val
+	return val, nil
 }
@@ -0,0 +1,284 @@
+package parens
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	vecBits  = 5
+	vecWidth = 1 << vecBits
+	vecMask  = vecWidth - 1
+)
+
+// PersistentVector is an immutable vector. Updates return a new vector
+// sharing the structure with the original and take O(log32 n) time.
+// Vector literals evaluate to PersistentVector. The zero value is an
+// empty vector.
+type PersistentVector struct {
+	cnt   int
+	shift uint
+	root  *vecNode
+	tail  []interface{}
+}
+
+// NewVector returns a vector of the items.
+func NewVector(items ...interface{}) *PersistentVector {
+	tv := (&PersistentVector{}).Transient()
+	for _, item := range items {
+		tv.Conj(item)
+	}
+	return tv.Persistent()
+}
+
+// Len returns the number of items in the vector.
+func (pv *PersistentVector) Len() int { return pv.cnt }
+
+// Nth returns the item at index i. ok is false if i is out of range.
+func (pv *PersistentVector) Nth(i int) (item interface{}, ok bool) {
+	if i < 0 || i >= pv.cnt {
+		return nil, false
+	}
+
+	return pv.leafFor(i)[i&vecMask], true
+}
+
+// Conj returns a new vector with the items added at the end.
+func (pv *PersistentVector) Conj(items ...interface{}) *PersistentVector {
+	if len(items) > 1 {
+		tv := pv.Transient()
+		for _, item := range items {
+			tv.Conj(item)
+		}
+		return tv.Persistent()
+	}
+
+	res := *pv
+	for _, item := range items {
+		res.conj(nil, item)
+	}
+	return &res
+}
+
+// Assoc returns a new vector with the item at index i replaced by v. If i
+// is same as the length, v is added at the end.
+func (pv *PersistentVector) Assoc(i int, v interface{}) (*PersistentVector, error) {
+	if i == pv.cnt {
+		return pv.Conj(v), nil
+	}
+
+	res := *pv
+	if err := res.assoc(nil, i, v); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ToSlice returns the items of the vector as a new Go slice.
+func (pv *PersistentVector) ToSlice() []interface{} {
+	res := make([]interface{}, 0, pv.cnt)
+	for i := 0; i < pv.cnt; i += vecWidth {
+		leaf := pv.leafFor(i)
+		if n := pv.cnt - i; n < len(leaf) {
+			leaf = leaf[:n]
+		}
+		res = append(res, leaf...)
+	}
+	return res
+}
+
+// Transient returns a transient copy of the vector for batch updates.
+func (pv *PersistentVector) Transient() *TransientVector {
+	tail := make([]interface{}, len(pv.tail), vecWidth)
+	copy(tail, pv.tail)
+
+	tv := &TransientVector{vec: *pv, edit: &editToken{}}
+	tv.vec.tail = tail
+	return tv
+}
+
+func (pv *PersistentVector) String() string {
+	parts := make([]string, 0, pv.cnt)
+	for _, item := range pv.ToSlice() {
+		parts = append(parts, fmt.Sprintf("%v", item))
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func (pv *PersistentVector) tailOffset() int {
+	if pv.cnt < vecWidth {
+		return 0
+	}
+	return ((pv.cnt - 1) >> vecBits) << vecBits
+}
+
+// leafFor returns the leaf array containing the index i.
+func (pv *PersistentVector) leafFor(i int) []interface{} {
+	if i >= pv.tailOffset() {
+		return pv.tail
+	}
+
+	node := pv.root
+	for level := pv.shift; level > 0; level -= vecBits {
+		node = node.items[(i>>level)&vecMask].(*vecNode)
+	}
+	return node.items[:]
+}
+
+// conj adds the item at the end. Nodes owned by edit are updated in place
+// and the tail is appended to in place if edit is not nil.
+func (pv *PersistentVector) conj(edit *editToken, item interface{}) {
+	if pv.root == nil {
+		pv.root, pv.shift = &vecNode{}, vecBits
+	}
+
+	if pv.cnt-pv.tailOffset() < vecWidth {
+		if edit == nil {
+			tail := make([]interface{}, len(pv.tail), len(pv.tail)+1)
+			copy(tail, pv.tail)
+			pv.tail = tail
+		}
+		pv.tail = append(pv.tail, item)
+		pv.cnt++
+		return
+	}
+
+	tailNode := &vecNode{edit: edit}
+	copy(tailNode.items[:], pv.tail)
+
+	if (pv.cnt >> vecBits) > (1 << pv.shift) {
+		root := &vecNode{edit: edit}
+		root.items[0] = pv.root
+		root.items[1] = newVecPath(edit, pv.shift, tailNode)
+		pv.root, pv.shift = root, pv.shift+vecBits
+	} else {
+		pv.root = pv.pushTail(edit, pv.shift, pv.root, tailNode)
+	}
+
+	pv.tail = make([]interface{}, 1, vecWidth)
+	pv.tail[0] = item
+	pv.cnt++
+}
+
+func (pv *PersistentVector) pushTail(edit *editToken, level uint, parent, tailNode *vecNode) *vecNode {
+	res := parent.editable(edit)
+	idx := ((pv.cnt - 1) >> level) & vecMask
+	if level == vecBits {
+		res.items[idx] = tailNode
+	} else if child, ok := parent.items[idx].(*vecNode); ok {
+		res.items[idx] = pv.pushTail(edit, level-vecBits, child, tailNode)
+	} else {
+		res.items[idx] = newVecPath(edit, level-vecBits, tailNode)
+	}
+	return res
+}
+
+func (pv *PersistentVector) assoc(edit *editToken, i int, v interface{}) error {
+	if i < 0 || i >= pv.cnt {
+		return fmt.Errorf("index %d out of range for vector of %d items", i, pv.cnt)
+	}
+
+	if i >= pv.tailOffset() {
+		if edit == nil {
+			tail := make([]interface{}, len(pv.tail))
+			copy(tail, pv.tail)
+			pv.tail = tail
+		}
+		pv.tail[i&vecMask] = v
+		return nil
+	}
+
+	pv.root = assocVecNode(edit, pv.shift, pv.root, i, v)
+	return nil
+}
+
+func assocVecNode(edit *editToken, level uint, node *vecNode, i int, v interface{}) *vecNode {
+	res := node.editable(edit)
+	if level == 0 {
+		res.items[i&vecMask] = v
+	} else {
+		idx := (i >> level) & vecMask
+		res.items[idx] = assocVecNode(edit, level-vecBits, node.items[idx].(*vecNode), i, v)
+	}
+	return res
+}
+
+func newVecPath(edit *editToken, level uint, node *vecNode) *vecNode {
+	if level == 0 {
+		return node
+	}
+
+	res := &vecNode{edit: edit}
+	res.items[0] = newVecPath(edit, level-vecBits, node)
+	return res
+}
+
+// TransientVector is a mutable copy of a PersistentVector used for making
+// many updates efficiently. Updates are made in place and the transient
+// must not be used after calling Persistent.
+type TransientVector struct {
+	vec  PersistentVector
+	edit *editToken
+}
+
+// Len returns the number of items in the vector.
+func (tv *TransientVector) Len() int { return tv.vec.cnt }
+
+// Conj adds the item at the end of the vector.
+func (tv *TransientVector) Conj(item interface{}) *TransientVector {
+	tv.ensureEditable()
+	tv.vec.conj(tv.edit, item)
+	return tv
+}
+
+// Assoc replaces the item at index i with v. If i is same as the length,
+// v is added at the end.
+func (tv *TransientVector) Assoc(i int, v interface{}) error {
+	tv.ensureEditable()
+	if i == tv.vec.cnt {
+		tv.vec.conj(tv.edit, v)
+		return nil
+	}
+	return tv.vec.assoc(tv.edit, i, v)
+}
+
+// Persistent returns the vector as a PersistentVector. The transient must
+// not be used afterwards.
+func (tv *TransientVector) Persistent() *PersistentVector {
+	tv.ensureEditable()
+	tv.edit = nil
+
+	res := tv.vec
+	res.tail = res.tail[:len(res.tail):len(res.tail)]
+	return &res
+}
+
+func (tv *TransientVector) ensureEditable() {
+	if tv.edit == nil {
+		panic("transient used after calling Persistent")
+	}
+}
+
+type vecNode struct {
+	edit  *editToken
+	items [vecWidth]interface{}
+}
+
+// editable returns the node itself if it is owned by edit and a copy owned
+// by edit otherwise.
+func (node *vecNode) editable(edit *editToken) *vecNode {
+	if edit != nil && node.edit == edit {
+		return node
+	}
+
+	res := *node
+	res.edit = edit
+	return &res
+}
+
+// editToken identifies the nodes created by a transient which can be
+// updated in place by it. It is not zero-sized so that every token has a
+// distinct address.
+type editToken struct {
+	_ byte
+}
@@ -0,0 +1,98 @@
+package parens_test
+
+import (
+	"testing"
+
+	"github.com/spy16/parens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistentVector(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int{0, 1, 32, 33, 1024, 1056, 1057, 40000} {
+		vec := &parens.PersistentVector{}
+		for i := 0; i < n; i++ {
+			vec = vec.Conj(i)
+		}
+
+		require.Equal(t, n, vec.Len())
+		for i := 0; i < n; i++ {
+			item, ok := vec.Nth(i)
+			require.True(t, ok)
+			require.Equal(t, i, item)
+		}
+
+		_, ok := vec.Nth(n)
+		assert.False(t, ok)
+		_, ok = vec.Nth(-1)
+		assert.False(t, ok)
+
+		assert.True(t, parens.Equal(vec, parens.NewVector(vec.ToSlice()...)))
+	}
+}
+
+func TestPersistentVector_Immutable(t *testing.T) {
+	t.Parallel()
+
+	items := make([]interface{}, 2000)
+	for i := range items {
+		items[i] = i
+	}
+	vec := parens.NewVector(items...)
+
+	appended := vec.Conj("x")
+	updated, err := vec.Assoc(1500, "y")
+	require.NoError(t, err)
+	tailUpdated, err := vec.Assoc(1999, "z")
+	require.NoError(t, err)
+
+	assert.Equal(t, items, vec.ToSlice())
+	assert.Equal(t, 2001, appended.Len())
+
+	item, _ := updated.Nth(1500)
+	assert.Equal(t, "y", item)
+	item, _ = tailUpdated.Nth(1999)
+	assert.Equal(t, "z", item)
+
+	item, _ = vec.Nth(1500)
+	assert.Equal(t, 1500, item)
+	item, _ = vec.Nth(1999)
+	assert.Equal(t, 1999, item)
+
+	appendedAgain, err := vec.Assoc(vec.Len(), "w")
+	require.NoError(t, err)
+	assert.Equal(t, 2001, appendedAgain.Len())
+
+	_, err = vec.Assoc(vec.Len()+1, "w")
+	assert.EqualError(t, err, "index 2001 out of range for vector of 2000 items")
+}
+
+func TestTransientVector(t *testing.T) {
+	t.Parallel()
+
+	base := parens.NewVector(1, 2, 3)
+
+	tv := base.Transient()
+	for i := 0; i < 100; i++ {
+		tv.Conj(i)
+	}
+	require.NoError(t, tv.Assoc(0, "a"))
+	assert.Equal(t, 103, tv.Len())
+
+	vec := tv.Persistent()
+	assert.Equal(t, 103, vec.Len())
+	item, _ := vec.Nth(0)
+	assert.Equal(t, "a", item)
+
+	assert.Equal(t, []interface{}{1, 2, 3}, base.ToSlice())
+	assert.Panics(t, func() { tv.Conj(1) })
+}
+
+func TestPersistentVector_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "[]", (&parens.PersistentVector{}).String())
+	assert.Equal(t, "[1 :a [hello]]", parens.NewVector(int64(1), parens.Keyword(":a"), parens.NewVector("hello")).String())
+}